@@ -0,0 +1,262 @@
+/*
+daily_open_meteo/backfill.go
+
+BackfillAllSensors fans DailyWeatherer-style work out across every
+sensor_set row, instead of requiring an operator to call DailyWeatherer once
+per sensor. Each (sensor_set, date window) pair runs on a bounded worker
+pool, with retries for transient provider errors, so a large multi-year
+backfill can run unattended and still report exactly which slices need a
+re-run.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/iterator"
+)
+
+func init() {
+	functions.HTTP("BackfillAllSensors", BackfillAllSensors)
+}
+
+// defaultWeatherConcurrency is used when WEATHER_CONCURRENCY is unset or
+// invalid.
+const defaultWeatherConcurrency = 4
+
+// maxBackfillWindowDays keeps a single provider request within Open-Meteo's
+// per-request range limits.
+const maxBackfillWindowDays = 31
+
+// backfillFailure describes one (sensor_set, date window) slice that failed
+// after exhausting retries, so an operator can re-run only that slice.
+type backfillFailure struct {
+	SensorSetID string `json:"sensor_set_id"`
+	Window      string `json:"window"`
+	Error       string `json:"error"`
+}
+
+// backfillResponse is the JSON body BackfillAllSensors returns.
+type backfillResponse struct {
+	SensorSets int               `json:"sensor_sets"`
+	Windows    int               `json:"windows"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     []backfillFailure `json:"failed"`
+}
+
+// backfillJob is one (sensor_set, date window) unit of work.
+type backfillJob struct {
+	sensorSetID string
+	startDate   string
+	endDate     string
+}
+
+// BackfillAllSensors is the entry point for a fleet-wide backfill: it reads
+// every sensor_set row and fetches+merges weather data for each one across
+// [start_date, end_date], chunked into <=31-day windows per provider limits.
+func BackfillAllSensors(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	projectID := os.Getenv("GCP_PROJECT")
+	if projectID == "" {
+		log.Println("ERROR: GCP_PROJECT environment variable not set")
+		http.Error(w, "GCP_PROJECT environment variable not set", http.StatusInternalServerError)
+		return
+	}
+
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if startDate == "" || endDate == "" {
+		http.Error(w, "start_date and end_date query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	windows, err := chunkDateRange(startDate, endDate, maxBackfillWindowDays)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid date range: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	concurrency := defaultWeatherConcurrency
+	if v := os.Getenv("WEATHER_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			concurrency = n
+		} else {
+			log.Printf("WARN: Ignoring invalid WEATHER_CONCURRENCY=%q, using default %d", v, defaultWeatherConcurrency)
+		}
+	}
+
+	client, err := bigquery.NewClient(ctx, projectID)
+	if err != nil {
+		log.Printf("ERROR: Failed to create BigQuery client: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create BigQuery client: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer client.Close()
+
+	sensorSetIDs, err := listSensorSetIDs(ctx, client, projectID)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jobs := make([]backfillJob, 0, len(sensorSetIDs)*len(windows))
+	for _, sensorSetID := range sensorSetIDs {
+		for _, window := range windows {
+			jobs = append(jobs, backfillJob{sensorSetID: sensorSetID, startDate: window[0], endDate: window[1]})
+		}
+	}
+	log.Printf("INFO: Backfilling %d sensor sets across %d windows (%d jobs, concurrency=%d)",
+		len(sensorSetIDs), len(windows), len(jobs), concurrency)
+
+	results := runBackfillJobs(ctx, client, projectID, jobs, concurrency)
+
+	resp := backfillResponse{SensorSets: len(sensorSetIDs), Windows: len(windows)}
+	for _, result := range results {
+		if result.Error != nil {
+			resp.Failed = append(resp.Failed, backfillFailure{
+				SensorSetID: result.job.sensorSetID,
+				Window:      fmt.Sprintf("%s..%s", result.job.startDate, result.job.endDate),
+				Error:       result.Error.Error(),
+			})
+			continue
+		}
+		resp.Succeeded++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(resp.Failed) > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("ERROR: Failed to encode backfill response: %v", err)
+	}
+}
+
+// backfillJobResult pairs a job with the (possibly nil) error it finished with.
+type backfillJobResult struct {
+	job   backfillJob
+	Error error
+}
+
+// runBackfillJobs runs every job on a worker pool bounded to concurrency
+// workers, using an errgroup so every job's goroutine is still tracked by a
+// WaitGroup internally. Each job always reports its own outcome rather than
+// aborting the group, so one bad window never stops the rest of the backfill.
+func runBackfillJobs(ctx context.Context, client *bigquery.Client, projectID string, jobs []backfillJob, concurrency int) []backfillJobResult {
+	results := make([]backfillJobResult, len(jobs))
+	g := &errgroup.Group{}
+	g.SetLimit(concurrency)
+
+	for i, job := range jobs {
+		i, job := i, job
+		g.Go(func() error {
+			results[i] = backfillJobResult{job: job, Error: runBackfillJob(ctx, client, projectID, job)}
+			return nil
+		})
+	}
+	_ = g.Wait() // job functions never return an error themselves
+
+	return results
+}
+
+// runBackfillJob fetches and merges one sensor_set's daily and hourly
+// weather data for one date window, retrying transient provider errors.
+func runBackfillJob(ctx context.Context, client *bigquery.Client, projectID string, job backfillJob) error {
+	// Backfill jobs hit the same providers DailyWeatherer does, so they're
+	// subject to the same per-sensor_set rate limit - otherwise a large or
+	// misconfigured backfill could blow through a provider's request quota
+	// in a way a single DailyWeatherer call never could.
+	allowed, err := allowRequest(ctx, job.sensorSetID)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("rate limit exceeded for sensor_set_id %q", job.sensorSetID)
+	}
+
+	sensorSetData, err := getSensorSet(ctx, client, projectID, job.sensorSetID)
+	if err != nil {
+		return fmt.Errorf("failed to get sensor set data: %w", err)
+	}
+
+	provider, err := resolveProvider(sensorSetData.Provider, sensorSetData)
+	if err != nil {
+		return fmt.Errorf("failed to resolve weather provider: %w", err)
+	}
+
+	var dailyRecords []WeatherRecord
+	err = withRetry(ctx, func() error {
+		var fetchErr error
+		dailyRecords, fetchErr = provider.FetchDaily(ctx, sensorSetData, job.startDate, job.endDate)
+		if fetchErr != nil {
+			weatherFetchTotal.WithLabelValues(provider.Name(), job.sensorSetID, "error").Inc()
+		}
+		return fetchErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch daily weather data: %w", err)
+	}
+	weatherFetchTotal.WithLabelValues(provider.Name(), job.sensorSetID, "ok").Inc()
+
+	var hourlyRecords []HourlyWeatherRecord
+	err = withRetry(ctx, func() error {
+		var fetchErr error
+		hourlyRecords, fetchErr = provider.FetchHourly(ctx, sensorSetData, job.startDate, job.endDate)
+		if fetchErr != nil {
+			weatherFetchTotal.WithLabelValues(provider.Name(), job.sensorSetID, "error").Inc()
+		}
+		return fetchErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch hourly weather data: %w", err)
+	}
+	weatherFetchTotal.WithLabelValues(provider.Name(), job.sensorSetID, "ok").Inc()
+
+	if err := insertDailyWeatherData(ctx, client, projectID, job.sensorSetID, provider.Name(), sensorSetData, dailyRecords, hourlyRecords); err != nil {
+		return fmt.Errorf("failed to merge daily weather data: %w", err)
+	}
+	if err := insertHourlyWeatherData(ctx, client, projectID, job.sensorSetID, provider.Name(), sensorSetData, hourlyRecords); err != nil {
+		return fmt.Errorf("failed to merge hourly weather data: %w", err)
+	}
+	return nil
+}
+
+// listSensorSetIDs returns every sensor_set_id in the sensor_set table.
+func listSensorSetIDs(ctx context.Context, client *bigquery.Client, projectID string) ([]string, error) {
+	queryString := fmt.Sprintf(`SELECT sensor_set_id FROM `+"`%s.sunlight_data.sensor_set`", projectID)
+	it, err := client.Query(queryString).Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sensor_set rows: %w", err)
+	}
+
+	var ids []string
+	for {
+		var row struct {
+			SensorSetID string `bigquery:"sensor_set_id"`
+		}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate sensor_set rows: %w", err)
+		}
+		ids = append(ids, row.SensorSetID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,64 @@
+/*
+daily_open_meteo/cache.go
+
+In-memory response cache for DailyWeatherer. Backfills are frequently
+re-triggered for the same sensor_set_id/date range (retries, overlapping
+Cloud Scheduler jobs, an operator re-running a failed step), so a short TTL
+cache lets those short-circuit before touching the provider API or
+BigQuery.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCacheTTL is how long a successful DailyWeatherer response is
+// considered fresh for the same sensor_set_id/start_date/end_date.
+const responseCacheTTL = 10 * time.Minute
+
+type cacheEntry struct {
+	body      string
+	expiresAt time.Time
+}
+
+// responseCache is a minimal TTL cache; entries are lazily evicted on read.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// dailyWeathererCache caches DailyWeatherer's success response text.
+var dailyWeathererCache = &responseCache{entries: make(map[string]cacheEntry)}
+
+// cacheKey must include the resolved provider: the same sensor_set_id and
+// date range fetched from two different providers (e.g. via the ?provider=
+// override) are two different responses, not a cache hit on one another.
+func cacheKey(sensorSetID, startDate, endDate, provider string) string {
+	return sensorSetID + "|" + startDate + "|" + endDate + "|" + provider
+}
+
+func (c *responseCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.body, true
+}
+
+func (c *responseCache) set(key, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{body: body, expiresAt: time.Now().Add(responseCacheTTL)}
+}
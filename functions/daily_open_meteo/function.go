@@ -1,7 +1,8 @@
 /*
 daily_open_meteo/function.go
 
-Collect daily and hourly weather data from Open-Meteo and store it in BigQuery.
+Collect daily and hourly weather data from a sensor's configured
+WeatherProvider (see provider.go) and store it in BigQuery.
 
 Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
 Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
@@ -12,9 +13,7 @@ package weather_function
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -32,91 +31,80 @@ func init() {
 
 // SensorSet represents the structure of the sensor_set metadata in BigQuery.
 type SensorSet struct {
-	Latitude  float64 `bigquery:"latitude"`
-	Longitude float64 `bigquery:"longitude"`
-	Timezone  string  `bigquery:"timezone"`
+	Latitude    float64 `bigquery:"latitude"`
+	Longitude   float64 `bigquery:"longitude"`
+	Timezone    string  `bigquery:"timezone"`
+	Provider    string  `bigquery:"provider"`
+	StationCode string  `bigquery:"station_code"`
 }
 
-// MeteoResponse represents the structure of the JSON response from the Open-Meteo API.
-type MeteoResponse struct {
-	Daily struct {
-		Time               []string  `json:"time"`
-		Sunrise            []string  `json:"sunrise"`
-		Sunset             []string  `json:"sunset"`
-		DaylightDuration   []float64 `json:"daylight_duration"`
-		SunshineDuration   []float64 `json:"sunshine_duration"`
-		Temperature2mMax   []float64 `json:"temperature_2m_max"`
-		Temperature2mMin   []float64 `json:"temperature_2m_min"`
-		UvIndexMax         []float64 `json:"uv_index_max"`
-		UvIndexClearSkyMax []float64 `json:"uv_index_clear_sky_max"`
-		RainSum            []float64 `json:"rain_sum"`
-		ShowersSum         []float64 `json:"showers_sum"`
-		PrecipitationSum   []float64 `json:"precipitation_sum"`
-		SnowfallSum        []float64 `json:"snowfall_sum"`
-		PrecipitationHours []float64 `json:"precipitation_hours"`
-	} `json:"daily"`
-	Hourly struct {
-		Time               []string  `json:"time"`
-		Temperature2m      []float64 `json:"temperature_2m"`
-		Precipitation      []float64 `json:"precipitation"`
-		RelativeHumidity2m []float64 `json:"relative_humidity_2m"`
-		CloudCover         []float64 `json:"cloud_cover"`
-		Visibility         []float64 `json:"visibility"`
-		SoilTemperature0cm []float64 `json:"soil_temperature_0cm"`
-		SoilMoisture1To3cm []float64 `json:"soil_moisture_1_to_3cm"`
-		UvIndex            []float64 `json:"uv_index"`
-		UvIndexClearSky    []float64 `json:"uv_index_clear_sky"`
-		ShortwaveRadiation []float64 `json:"shortwave_radiation"`
-		DirectRadiation    []float64 `json:"direct_radiation"`
-		WindSpeed10m       []float64 `json:"wind_speed_10m"`
-	} `json:"hourly"`
-}
-
-// WeatherRecord represents a single row in the daily_historical_weather BigQuery table.
+// WeatherRecord represents a single row in the daily_historical_weather
+// BigQuery table. Not every WeatherProvider reports every column (e.g.
+// METARProvider only reports Temperature2mMax/Min) - fields a provider
+// doesn't measure use BigQuery's nullable types and are left at their zero
+// value (Valid: false), so dailyMergeSQL's MERGE can tell "not measured by
+// this provider" apart from "measured as zero" and leave an existing value
+// in place instead of clobbering it with zero.
 type WeatherRecord struct {
-	Date               string    `bigquery:"date"`
-	Sunrise            time.Time `bigquery:"sunrise"`
-	Sunset             time.Time `bigquery:"sunset"`
-	DaylightDuration   float64   `bigquery:"daylight_duration"`
-	SunshineDuration   float64   `bigquery:"sunshine_duration"`
-	Temperature2mMax   float64   `bigquery:"temperature_2m_max"`
-	Temperature2mMin   float64   `bigquery:"temperature_2m_min"`
-	UvIndexMax         float64   `bigquery:"uv_index_max"`
-	UvIndexClearSkyMax float64   `bigquery:"uv_index_clear_sky_max"`
-	RainSum            float64   `bigquery:"rain_sum"`
-	ShowersSum         float64   `bigquery:"showers_sum"`
-	PrecipitationSum   float64   `bigquery:"precipitation_sum"`
-	SnowfallSum        float64   `bigquery:"snowfall_sum"`
-	PrecipitationHours float64   `bigquery:"precipitation_hours"` // Fixed: was precipitation_hour
-	DataSource         string    `bigquery:"data_source"`
-	SensorSet          string    `bigquery:"sensor_set_id"`
-	Timezone           string    `bigquery:"timezone"`
-	Latitude           float64   `bigquery:"latitude"`
-	Longitude          float64   `bigquery:"longitude"`
-	LastUpdated        time.Time `bigquery:"last_updated"`
+	Date               string                 `bigquery:"date"`
+	Sunrise            bigquery.NullTimestamp `bigquery:"sunrise"`
+	Sunset             bigquery.NullTimestamp `bigquery:"sunset"`
+	DaylightDuration   bigquery.NullFloat64   `bigquery:"daylight_duration"`
+	SunshineDuration   bigquery.NullFloat64   `bigquery:"sunshine_duration"`
+	Temperature2mMax   float64                `bigquery:"temperature_2m_max"`
+	Temperature2mMin   float64                `bigquery:"temperature_2m_min"`
+	UvIndexMax         bigquery.NullFloat64   `bigquery:"uv_index_max"`
+	UvIndexClearSkyMax bigquery.NullFloat64   `bigquery:"uv_index_clear_sky_max"`
+	RainSum            bigquery.NullFloat64   `bigquery:"rain_sum"`
+	ShowersSum         bigquery.NullFloat64   `bigquery:"showers_sum"`
+	PrecipitationSum   bigquery.NullFloat64   `bigquery:"precipitation_sum"`
+	SnowfallSum        bigquery.NullFloat64   `bigquery:"snowfall_sum"`
+	PrecipitationHours bigquery.NullFloat64   `bigquery:"precipitation_hours"`
+	DataSource         string                 `bigquery:"data_source"`
+	SensorSet          string                 `bigquery:"sensor_set_id"`
+	Timezone           string                 `bigquery:"timezone"`
+	Latitude           float64                `bigquery:"latitude"`
+	Longitude          float64                `bigquery:"longitude"`
+	LastUpdated        time.Time              `bigquery:"last_updated"`
+
+	// Derived fields computed locally in insertDailyWeatherData (see
+	// solar.go); no provider returns these directly.
+	SolarNoon                 time.Time `bigquery:"solar_noon"`
+	CivilTwilightBegin        time.Time `bigquery:"civil_twilight_begin"`
+	CivilTwilightEnd          time.Time `bigquery:"civil_twilight_end"`
+	NauticalTwilightBegin     time.Time `bigquery:"nautical_twilight_begin"`
+	NauticalTwilightEnd       time.Time `bigquery:"nautical_twilight_end"`
+	AstronomicalTwilightBegin time.Time `bigquery:"astronomical_twilight_begin"`
+	AstronomicalTwilightEnd   time.Time `bigquery:"astronomical_twilight_end"`
+	GrowingDegreeDays         float64   `bigquery:"growing_degree_days"`
+	CloudCoverMean            float64   `bigquery:"cloud_cover_mean"`
+	SkyCondition              string    `bigquery:"sky_condition"`
 }
 
-// HourlyWeatherRecord represents a single row in the hourly_historical_weather BigQuery table.
+// HourlyWeatherRecord represents a single row in the
+// hourly_historical_weather BigQuery table. As with WeatherRecord, columns
+// not every provider measures use BigQuery's nullable types so a MERGE from
+// a provider that doesn't report them doesn't clobber an existing value.
 type HourlyWeatherRecord struct {
-	Time               time.Time `bigquery:"time"`
-	SensorSetID        string    `bigquery:"sensor_set_id"`
-	Temperature2m      float64   `bigquery:"temperature_2m"`
-	Precipitation      float64   `bigquery:"precipitation"`
-	RelativeHumidity2m float64   `bigquery:"relative_humidity_2m"`
-	CloudCover         float64   `bigquery:"cloud_cover"`
-	Visibility         float64   `bigquery:"visibility"`
-	SoilTemperature0cm float64   `bigquery:"soil_temperature_0cm"`
-	SoilMoisture1To3cm float64   `bigquery:"soil_moisture_1_to_3cm"`
-	UvIndex            float64   `bigquery:"uv_index"`
-	UvIndexClearSky    float64   `bigquery:"uv_index_clear_sky"`
-	ShortwaveRadiation float64   `bigquery:"shortwave_radiation"`
-	DirectRadiation    float64   `bigquery:"direct_radiation"`
-	WindSpeed10m       float64   `bigquery:"wind_speed_10m"`
-	Timezone           string    `bigquery:"timezone"`
-	Latitude           float64   `bigquery:"latitude"`
-	Longitude          float64   `bigquery:"longitude"`
-	DataSource         string    `bigquery:"data_source"`
-	LastUpdated        time.Time `bigquery:"last_updated"`
+	Time               time.Time            `bigquery:"time"`
+	SensorSetID        string               `bigquery:"sensor_set_id"`
+	Temperature2m      float64              `bigquery:"temperature_2m"`
+	Precipitation      bigquery.NullFloat64 `bigquery:"precipitation"`
+	RelativeHumidity2m bigquery.NullFloat64 `bigquery:"relative_humidity_2m"`
+	CloudCover         float64              `bigquery:"cloud_cover"`
+	Visibility         bigquery.NullFloat64 `bigquery:"visibility"`
+	SoilTemperature0cm bigquery.NullFloat64 `bigquery:"soil_temperature_0cm"`
+	SoilMoisture1To3cm bigquery.NullFloat64 `bigquery:"soil_moisture_1_to_3cm"`
+	UvIndex            bigquery.NullFloat64 `bigquery:"uv_index"`
+	UvIndexClearSky    bigquery.NullFloat64 `bigquery:"uv_index_clear_sky"`
+	ShortwaveRadiation bigquery.NullFloat64 `bigquery:"shortwave_radiation"`
+	DirectRadiation    bigquery.NullFloat64 `bigquery:"direct_radiation"`
+	WindSpeed10m       float64              `bigquery:"wind_speed_10m"`
+	Timezone           string               `bigquery:"timezone"`
+	Latitude           float64              `bigquery:"latitude"`
+	Longitude          float64              `bigquery:"longitude"`
+	DataSource         string               `bigquery:"data_source"`
+	LastUpdated        time.Time            `bigquery:"last_updated"`
 }
 
 // DailyWeatherer is the entry point for the Cloud Function.
@@ -133,8 +121,9 @@ func DailyWeatherer(w http.ResponseWriter, r *http.Request) {
 	sensorSet := r.URL.Query().Get("sensor_set_id")
 	startDate := r.URL.Query().Get("start_date")
 	endDate := r.URL.Query().Get("end_date")
+	providerOverride := r.URL.Query().Get("provider")
 
-	log.Printf("INFO: Received request with parameters: sensor_set_id='%s', start_date='%s', end_date='%s'", sensorSet, startDate, endDate)
+	log.Printf("INFO: Received request with parameters: sensor_set_id='%s', start_date='%s', end_date='%s', provider='%s'", sensorSet, startDate, endDate, providerOverride)
 
 	if sensorSet == "" {
 		log.Println("ERROR: Missing sensor_set_id parameter")
@@ -149,6 +138,38 @@ func DailyWeatherer(w http.ResponseWriter, r *http.Request) {
 		log.Printf("INFO: Defaulting to date range: start_date='%s', end_date='%s'", startDate, endDate)
 	}
 
+	// dry_run=true fetches from the provider and reports row counts without
+	// writing to BigQuery (sensor_set metadata is still read from it), e.g.
+	// `--dry-run` for an operator re-running a
+	// backfill who wants to sanity-check row counts first.
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	allowed, err := allowRequest(ctx, sensorSet)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		log.Printf("WARN: Rate limit exceeded for sensor_set_id='%s'", sensorSet)
+		http.Error(w, "Rate limit exceeded for this sensor_set_id, try again shortly", http.StatusTooManyRequests)
+		return
+	}
+
+	// When ?provider= is given, the cache key is already fully known from the
+	// query parameters alone, so check it before touching BigQuery at all.
+	// Without an override, the provider defaults to the sensor_set's own
+	// provider column, which can only be resolved by fetching sensor set
+	// metadata below.
+	if providerOverride != "" && !dryRun {
+		key := cacheKey(sensorSet, startDate, endDate, providerOverride)
+		if cached, ok := dailyWeathererCache.get(key); ok {
+			log.Printf("INFO: Serving cached response for sensor_set_id='%s', start_date='%s', end_date='%s', provider='%s'", sensorSet, startDate, endDate, providerOverride)
+			fmt.Fprintln(w, cached)
+			return
+		}
+	}
+
 	// Create a BigQuery client
 	client, err := bigquery.NewClient(ctx, projectID)
 	if err != nil {
@@ -165,54 +186,102 @@ func DailyWeatherer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get weather data from Open-Meteo
-	weatherData, err := getWeatherData(sensorSetData, startDate, endDate)
+	// Pick the WeatherProvider for this sensor set: ?provider= overrides the
+	// sensor_set.provider column, which in turn defaults to Open-Meteo.
+	provider, err := resolveProvider(providerOverride, sensorSetData)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get weather data: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to resolve weather provider: %v", err), http.StatusBadRequest)
 		return
 	}
+	log.Printf("INFO: Using weather provider '%s' for sensor_set_id='%s'", provider.Name(), sensorSet)
+
+	// The cache key includes the resolved provider, since the same
+	// sensor_set_id/date range fetched through two different providers are
+	// two different responses. When providerOverride was set, this is the
+	// same key already checked above; the second lookup only matters when
+	// providerOverride is empty and the provider came from sensor_set data.
+	key := cacheKey(sensorSet, startDate, endDate, provider.Name())
+	if !dryRun {
+		if cached, ok := dailyWeathererCache.get(key); ok {
+			log.Printf("INFO: Serving cached response for sensor_set_id='%s', start_date='%s', end_date='%s', provider='%s'", sensorSet, startDate, endDate, provider.Name())
+			fmt.Fprintln(w, cached)
+			return
+		}
+	}
 
-	// Use goroutines to insert daily and hourly data concurrently
+	// Fetch daily and hourly data concurrently. The hourly records are
+	// needed by insertDailyWeatherData (to derive a daily sky_condition),
+	// so inserting has to wait for both fetches to finish.
 	var wg sync.WaitGroup
+	var dailyRecords []WeatherRecord
+	var hourlyRecords []HourlyWeatherRecord
 	var dailyErr, hourlyErr error
 
-	// Insert daily weather data
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		dailyErr = insertDailyWeatherData(ctx, client, projectID, sensorSet, sensorSetData, weatherData)
+		records, err := provider.FetchDaily(ctx, sensorSetData, startDate, endDate)
+		if err != nil {
+			weatherFetchTotal.WithLabelValues(provider.Name(), sensorSet, "error").Inc()
+			dailyErr = fmt.Errorf("failed to fetch daily weather data: %w", err)
+			return
+		}
+		weatherFetchTotal.WithLabelValues(provider.Name(), sensorSet, "ok").Inc()
+		dailyRecords = records
 	}()
 
-	// Insert hourly weather data
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		hourlyErr = insertHourlyWeatherData(ctx, client, projectID, sensorSet, sensorSetData, weatherData)
+		records, err := provider.FetchHourly(ctx, sensorSetData, startDate, endDate)
+		if err != nil {
+			weatherFetchTotal.WithLabelValues(provider.Name(), sensorSet, "error").Inc()
+			hourlyErr = fmt.Errorf("failed to fetch hourly weather data: %w", err)
+			return
+		}
+		weatherFetchTotal.WithLabelValues(provider.Name(), sensorSet, "ok").Inc()
+		hourlyRecords = records
 	}()
 
-	// Wait for both goroutines to complete
 	wg.Wait()
 
-	// Check for errors
 	if dailyErr != nil {
-		log.Printf("ERROR: Failed to insert daily weather data: %v", dailyErr)
-		http.Error(w, fmt.Sprintf("Failed to insert daily weather data: %v", dailyErr), http.StatusInternalServerError)
+		log.Printf("ERROR: %v", dailyErr)
+		http.Error(w, dailyErr.Error(), http.StatusInternalServerError)
 		return
 	}
-
 	if hourlyErr != nil {
-		log.Printf("ERROR: Failed to insert hourly weather data: %v", hourlyErr)
-		http.Error(w, fmt.Sprintf("Failed to insert hourly weather data: %v", hourlyErr), http.StatusInternalServerError)
+		log.Printf("ERROR: %v", hourlyErr)
+		http.Error(w, hourlyErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if dryRun {
+		log.Printf("INFO: [dry-run] Complete: %d daily, %d hourly records would be merged.", len(dailyRecords), len(hourlyRecords))
+		fmt.Fprintf(w, "[dry-run] %d daily, %d hourly records would be merged.\n", len(dailyRecords), len(hourlyRecords))
+		return
+	}
+
+	if err := insertDailyWeatherData(ctx, client, projectID, sensorSet, provider.Name(), sensorSetData, dailyRecords, hourlyRecords); err != nil {
+		log.Printf("ERROR: Failed to insert daily weather data: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to insert daily weather data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := insertHourlyWeatherData(ctx, client, projectID, sensorSet, provider.Name(), sensorSetData, hourlyRecords); err != nil {
+		log.Printf("ERROR: Failed to insert hourly weather data: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to insert hourly weather data: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	log.Println("INFO: Successfully fetched and stored daily and hourly weather data.")
-	fmt.Fprintln(w, "Successfully fetched and stored daily and hourly weather data.")
+	successMessage := "Successfully fetched and stored daily and hourly weather data."
+	dailyWeathererCache.set(key, successMessage)
+	fmt.Fprintln(w, successMessage)
 }
 
 func getSensorSet(ctx context.Context, client *bigquery.Client, projectID, sensorSetID string) (*SensorSet, error) {
 	queryString := fmt.Sprintf(
-		`SELECT latitude, longitude, timezone FROM `+"`%s.sunlight_data.sensor_set`"+` WHERE sensor_set_id = @sensor_set_id`,
+		`SELECT latitude, longitude, timezone, provider, station_code FROM `+"`%s.sunlight_data.sensor_set`"+` WHERE sensor_set_id = @sensor_set_id`,
 		projectID,
 	)
 	log.Printf("INFO: Executing BigQuery query: %s with sensor_set_id: %s", queryString, sensorSetID)
@@ -244,242 +313,149 @@ func getSensorSet(ctx context.Context, client *bigquery.Client, projectID, senso
 	return &ss, nil
 }
 
-func getWeatherData(sensorSet *SensorSet, startDate, endDate string) (*MeteoResponse, error) {
-	// Updated URL to include hourly parameters
-	url := fmt.Sprintf(
-		"https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&daily=sunrise,sunset,daylight_duration,sunshine_duration,temperature_2m_max,temperature_2m_min,uv_index_max,uv_index_clear_sky_max,rain_sum,showers_sum,precipitation_sum,snowfall_sum,precipitation_hours&hourly=temperature_2m,precipitation,relative_humidity_2m,cloud_cover,visibility,soil_temperature_0cm,soil_moisture_1_to_3cm,uv_index,uv_index_clear_sky,shortwave_radiation,direct_radiation,wind_speed_10m&start_date=%s&end_date=%s",
-		sensorSet.Latitude, sensorSet.Longitude, startDate, endDate,
-	)
-	log.Printf("INFO: Calling Open-Meteo API: %s", url)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Printf("ERROR: Failed to call Open-Meteo API: %v", err)
-		return nil, err
+// insertDailyWeatherData stages every daily record for this request into a
+// short-lived staging table with a single Inserter.Put call, then runs one
+// MERGE from that staging table into daily_historical_weather. This trades
+// hundreds of serial per-row MERGE jobs (one per day of backfill) for a
+// single streaming insert plus a single query job.
+//
+// hourlyRecords is the same request's hourly fetch, used only to derive a
+// daily cloud_cover_mean/sky_condition (see solar.go); it is not inserted
+// here.
+func insertDailyWeatherData(ctx context.Context, client *bigquery.Client, projectID, sensorSetID, dataSource string, sensorSetData *SensorSet, records []WeatherRecord, hourlyRecords []HourlyWeatherRecord) error {
+	if len(records) == 0 {
+		log.Println("INFO: No daily weather records to merge.")
+		return nil
 	}
-	defer resp.Body.Close()
+	defer prometheusTimer(weatherBigQueryMergeDuration.WithLabelValues("daily_historical_weather"))()
+
+	now := time.Now().UTC()
+	cloudCoverByDate := meanCloudCoverByDate(hourlyRecords)
+	rows := make([]*WeatherRecord, len(records))
+	for i := range records {
+		record := records[i]
+		record.DataSource = dataSource
+		record.SensorSet = sensorSetID
+		record.Timezone = sensorSetData.Timezone
+		record.Latitude = sensorSetData.Latitude
+		record.Longitude = sensorSetData.Longitude
+		record.LastUpdated = now
+
+		if date, err := time.Parse("2006-01-02", record.Date); err != nil {
+			log.Printf("WARN: Could not parse date %q for solar calculations: %v", record.Date, err)
+		} else {
+			solar := computeSolarDay(date, sensorSetData.Latitude, sensorSetData.Longitude)
+			record.SolarNoon = solar.Noon
+			record.CivilTwilightBegin = solar.CivilDawn
+			record.CivilTwilightEnd = solar.CivilDusk
+			record.NauticalTwilightBegin = solar.NauticalDawn
+			record.NauticalTwilightEnd = solar.NauticalDusk
+			record.AstronomicalTwilightBegin = solar.AstronomicalDawn
+			record.AstronomicalTwilightEnd = solar.AstronomicalDusk
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		err := fmt.Errorf("Open-Meteo API returned non-200 status: %d. Body: %s", resp.StatusCode, string(bodyBytes))
-		log.Printf("ERROR: %v", err)
-		return nil, err
-	}
+		record.GrowingDegreeDays = growingDegreeDays(record.Temperature2mMax, record.Temperature2mMin, growingDegreeDayBaseC)
+		if cloudCover, ok := cloudCoverByDate[record.Date]; ok {
+			record.CloudCoverMean = cloudCover
+			record.SkyCondition = skyConditionFromCloudCover(cloudCover)
+		}
 
-	var meteoResp MeteoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&meteoResp); err != nil {
-		log.Printf("ERROR: Failed to decode Open-Meteo JSON response: %v", err)
-		return nil, err
+		rows[i] = &record
 	}
 
-	log.Printf("INFO: Successfully received and decoded data from Open-Meteo. Daily records: %d, Hourly records: %d",
-		len(meteoResp.Daily.Time), len(meteoResp.Hourly.Time))
-	return &meteoResp, nil
-}
+	schema, err := bigquery.InferSchema(WeatherRecord{})
+	if err != nil {
+		return fmt.Errorf("failed to infer daily staging schema: %w", err)
+	}
 
-func insertDailyWeatherData(ctx context.Context, client *bigquery.Client, projectID, sensorSetID string, sensorSetData *SensorSet, weatherData *MeteoResponse) error {
-	log.Printf("INFO: Preparing to insert %d daily weather records into BigQuery.", len(weatherData.Daily.Time))
-
-	for i, t := range weatherData.Daily.Time {
-		sunrise, _ := time.Parse("2006-01-02T15:04", weatherData.Daily.Sunrise[i])
-		sunset, _ := time.Parse("2006-01-02T15:04", weatherData.Daily.Sunset[i])
-
-		q := client.Query(fmt.Sprintf(`
-			MERGE `+"`%s.sunlight_data.daily_historical_weather`"+` T
-			USING (
-				SELECT
-					CAST(@date AS DATE) as date,
-					@sunrise as sunrise,
-					@sunset as sunset,
-					@daylight_duration as daylight_duration,
-					@sunshine_duration as sunshine_duration,
-					@temperature_2m_max as temperature_2m_max,
-					@temperature_2m_min as temperature_2m_min,
-					@uv_index_max as uv_index_max,
-					@uv_index_clear_sky_max as uv_index_clear_sky_max,
-					@rain_sum as rain_sum,
-					@showers_sum as showers_sum,
-					@precipitation_sum as precipitation_sum,
-					@snowfall_sum as snowfall_sum,
-					@precipitation_hours as precipitation_hours,
-					@data_source as data_source,
-					@sensor_set_id as sensor_set_id,
-					@timezone as timezone,
-					@latitude as latitude,
-					@longitude as longitude,
-					@last_updated as last_updated
-			) S
-			ON T.date = S.date AND T.sensor_set_id = S.sensor_set_id
-			WHEN MATCHED THEN
-				UPDATE SET
-					sunrise = S.sunrise,
-					sunset = S.sunset,
-					daylight_duration = S.daylight_duration,
-					sunshine_duration = S.sunshine_duration,
-					temperature_2m_max = S.temperature_2m_max,
-					temperature_2m_min = S.temperature_2m_min,
-					uv_index_max = S.uv_index_max,
-					uv_index_clear_sky_max = S.uv_index_clear_sky_max,
-					rain_sum = S.rain_sum,
-					showers_sum = S.showers_sum,
-					precipitation_sum = S.precipitation_sum,
-					snowfall_sum = S.snowfall_sum,
-					precipitation_hours = S.precipitation_hours,
-					data_source = S.data_source,
-					timezone = S.timezone,
-					latitude = S.latitude,
-					longitude = S.longitude,
-					last_updated = S.last_updated
-			WHEN NOT MATCHED THEN
-				INSERT (date, sunrise, sunset, daylight_duration, sunshine_duration, temperature_2m_max, temperature_2m_min, uv_index_max, uv_index_clear_sky_max, rain_sum, showers_sum, precipitation_sum, snowfall_sum, precipitation_hours, data_source, sensor_set_id, timezone, latitude, longitude, last_updated)
-				VALUES(date, sunrise, sunset, daylight_duration, sunshine_duration, temperature_2m_max, temperature_2m_min, uv_index_max, uv_index_clear_sky_max, rain_sum, showers_sum, precipitation_sum, snowfall_sum, precipitation_hours, data_source, sensor_set_id, timezone, latitude, longitude, last_updated)
-		`, projectID))
-
-		q.Parameters = []bigquery.QueryParameter{
-			{Name: "date", Value: t},
-			{Name: "sunrise", Value: sunrise},
-			{Name: "sunset", Value: sunset},
-			{Name: "daylight_duration", Value: weatherData.Daily.DaylightDuration[i]},
-			{Name: "sunshine_duration", Value: weatherData.Daily.SunshineDuration[i]},
-			{Name: "temperature_2m_max", Value: weatherData.Daily.Temperature2mMax[i]},
-			{Name: "temperature_2m_min", Value: weatherData.Daily.Temperature2mMin[i]},
-			{Name: "uv_index_max", Value: weatherData.Daily.UvIndexMax[i]},
-			{Name: "uv_index_clear_sky_max", Value: weatherData.Daily.UvIndexClearSkyMax[i]},
-			{Name: "rain_sum", Value: weatherData.Daily.RainSum[i]},
-			{Name: "showers_sum", Value: weatherData.Daily.ShowersSum[i]},
-			{Name: "precipitation_sum", Value: weatherData.Daily.PrecipitationSum[i]},
-			{Name: "snowfall_sum", Value: weatherData.Daily.SnowfallSum[i]},
-			{Name: "precipitation_hours", Value: weatherData.Daily.PrecipitationHours[i]}, // Fixed parameter name
-			{Name: "data_source", Value: "open-meteo"},
-			{Name: "sensor_set_id", Value: sensorSetID},
-			{Name: "timezone", Value: sensorSetData.Timezone},
-			{Name: "latitude", Value: sensorSetData.Latitude},
-			{Name: "longitude", Value: sensorSetData.Longitude},
-			{Name: "last_updated", Value: time.Now().UTC()},
+	stagingTable := stagingTableName("daily_weather", sensorSetID)
+	dataset := client.Dataset("sunlight_data")
+	if err := dataset.Table(stagingTable).Create(ctx, &bigquery.TableMetadata{Schema: schema}); err != nil {
+		return fmt.Errorf("failed to create daily staging table %s: %w", stagingTable, err)
+	}
+	defer func() {
+		// Dropping the table (rather than deleting its rows) sidesteps
+		// BigQuery's restriction on mutating recently streamed rows.
+		if err := dataset.Table(stagingTable).Delete(ctx); err != nil {
+			log.Printf("WARN: Failed to clean up daily staging table %s: %v", stagingTable, err)
 		}
+	}()
 
-		job, err := q.Run(ctx)
-		if err != nil {
-			return err
-		}
-		status, err := job.Wait(ctx)
-		if err != nil {
-			return err
-		}
-		if err := status.Err(); err != nil {
-			log.Printf("BigQuery daily job failed: %v", err)
-			return err
-		}
+	if err := dataset.Table(stagingTable).Inserter().Put(ctx, rows); err != nil {
+		return fmt.Errorf("failed to stream %d daily records into staging table %s: %w", len(rows), stagingTable, err)
 	}
 
-	log.Printf("INFO: Successfully inserted %d daily weather records.", len(weatherData.Daily.Time))
+	job, err := client.Query(dailyMergeSQL(projectID, stagingTable)).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to run daily merge: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for daily merge job: %w", err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("daily merge job failed: %w", err)
+	}
+
+	log.Printf("INFO: Successfully merged %d daily weather records.", len(rows))
 	return nil
 }
 
-func insertHourlyWeatherData(ctx context.Context, client *bigquery.Client, projectID, sensorSetID string, sensorSetData *SensorSet, weatherData *MeteoResponse) error {
-	log.Printf("INFO: Preparing to insert %d hourly weather records into BigQuery.", len(weatherData.Hourly.Time))
+// insertHourlyWeatherData is the hourly_historical_weather counterpart of
+// insertDailyWeatherData; see its doc comment for the staging-table
+// rationale.
+func insertHourlyWeatherData(ctx context.Context, client *bigquery.Client, projectID, sensorSetID, dataSource string, sensorSetData *SensorSet, records []HourlyWeatherRecord) error {
+	if len(records) == 0 {
+		log.Println("INFO: No hourly weather records to merge.")
+		return nil
+	}
+	defer prometheusTimer(weatherBigQueryMergeDuration.WithLabelValues("hourly_historical_weather"))()
+
+	now := time.Now().UTC()
+	rows := make([]*HourlyWeatherRecord, len(records))
+	for i := range records {
+		record := records[i]
+		record.SensorSetID = sensorSetID
+		record.Timezone = sensorSetData.Timezone
+		record.Latitude = sensorSetData.Latitude
+		record.Longitude = sensorSetData.Longitude
+		record.DataSource = dataSource
+		record.LastUpdated = now
+		rows[i] = &record
+	}
 
-	for i, timeStr := range weatherData.Hourly.Time {
-		// Parse the ISO 8601 timestamp
-		hourlyTime, err := time.Parse("2006-01-02T15:04", timeStr)
-		if err != nil {
-			log.Printf("ERROR: Failed to parse hourly timestamp '%s': %v", timeStr, err)
-			continue
-		}
+	schema, err := bigquery.InferSchema(HourlyWeatherRecord{})
+	if err != nil {
+		return fmt.Errorf("failed to infer hourly staging schema: %w", err)
+	}
 
-		q := client.Query(fmt.Sprintf(`
-			MERGE `+"`%s.sunlight_data.hourly_historical_weather`"+` T
-			USING (
-				SELECT
-					@time as time,
-					@sensor_set_id as sensor_set_id,
-					@temperature_2m as temperature_2m,
-					@precipitation as precipitation,
-					@relative_humidity_2m as relative_humidity_2m,
-					@cloud_cover as cloud_cover,
-					@visibility as visibility,
-					@soil_temperature_0cm as soil_temperature_0cm,
-					@soil_moisture_1_to_3cm as soil_moisture_1_to_3cm,
-					@uv_index as uv_index,
-					@uv_index_clear_sky as uv_index_clear_sky,
-					@shortwave_radiation as shortwave_radiation,
-					@direct_radiation as direct_radiation,
-					@wind_speed_10m as wind_speed_10m,
-					@timezone as timezone,
-					@latitude as latitude,
-					@longitude as longitude,
-					@data_source as data_source,
-					@last_updated as last_updated
-			) S
-			ON T.time = S.time AND T.sensor_set_id = S.sensor_set_id
-			WHEN MATCHED THEN
-				UPDATE SET
-					temperature_2m = S.temperature_2m,
-					precipitation = S.precipitation,
-					relative_humidity_2m = S.relative_humidity_2m,
-					cloud_cover = S.cloud_cover,
-					visibility = S.visibility,
-					soil_temperature_0cm = S.soil_temperature_0cm,
-					soil_moisture_1_to_3cm = S.soil_moisture_1_to_3cm,
-					uv_index = S.uv_index,
-					uv_index_clear_sky = S.uv_index_clear_sky,
-					shortwave_radiation = S.shortwave_radiation,
-					direct_radiation = S.direct_radiation,
-					wind_speed_10m = S.wind_speed_10m,
-					timezone = S.timezone,
-					latitude = S.latitude,
-					longitude = S.longitude,
-					data_source = S.data_source,
-					last_updated = S.last_updated
-			WHEN NOT MATCHED THEN
-				INSERT (time, sensor_set_id, temperature_2m, precipitation, relative_humidity_2m, cloud_cover, visibility, soil_temperature_0cm, soil_moisture_1_to_3cm, uv_index, uv_index_clear_sky, shortwave_radiation, direct_radiation, wind_speed_10m, timezone, latitude, longitude, data_source, last_updated)
-				VALUES(time, sensor_set_id, temperature_2m, precipitation, relative_humidity_2m, cloud_cover, visibility, soil_temperature_0cm, soil_moisture_1_to_3cm, uv_index, uv_index_clear_sky, shortwave_radiation, direct_radiation, wind_speed_10m, timezone, latitude, longitude, data_source, last_updated)
-		`, projectID))
-
-		// Helper function to safely get float value from slice
-		getFloatValue := func(slice []float64, index int) float64 {
-			if index < len(slice) {
-				return slice[index]
-			}
-			return 0.0
+	stagingTable := stagingTableName("hourly_weather", sensorSetID)
+	dataset := client.Dataset("sunlight_data")
+	if err := dataset.Table(stagingTable).Create(ctx, &bigquery.TableMetadata{Schema: schema}); err != nil {
+		return fmt.Errorf("failed to create hourly staging table %s: %w", stagingTable, err)
+	}
+	defer func() {
+		if err := dataset.Table(stagingTable).Delete(ctx); err != nil {
+			log.Printf("WARN: Failed to clean up hourly staging table %s: %v", stagingTable, err)
 		}
+	}()
 
-		q.Parameters = []bigquery.QueryParameter{
-			{Name: "time", Value: hourlyTime},
-			{Name: "sensor_set_id", Value: sensorSetID},
-			{Name: "temperature_2m", Value: getFloatValue(weatherData.Hourly.Temperature2m, i)},
-			{Name: "precipitation", Value: getFloatValue(weatherData.Hourly.Precipitation, i)},
-			{Name: "relative_humidity_2m", Value: getFloatValue(weatherData.Hourly.RelativeHumidity2m, i)},
-			{Name: "cloud_cover", Value: getFloatValue(weatherData.Hourly.CloudCover, i)},
-			{Name: "visibility", Value: getFloatValue(weatherData.Hourly.Visibility, i)},
-			{Name: "soil_temperature_0cm", Value: getFloatValue(weatherData.Hourly.SoilTemperature0cm, i)},
-			{Name: "soil_moisture_1_to_3cm", Value: getFloatValue(weatherData.Hourly.SoilMoisture1To3cm, i)},
-			{Name: "uv_index", Value: getFloatValue(weatherData.Hourly.UvIndex, i)},
-			{Name: "uv_index_clear_sky", Value: getFloatValue(weatherData.Hourly.UvIndexClearSky, i)},
-			{Name: "shortwave_radiation", Value: getFloatValue(weatherData.Hourly.ShortwaveRadiation, i)},
-			{Name: "direct_radiation", Value: getFloatValue(weatherData.Hourly.DirectRadiation, i)},
-			{Name: "wind_speed_10m", Value: getFloatValue(weatherData.Hourly.WindSpeed10m, i)},
-			{Name: "timezone", Value: sensorSetData.Timezone},
-			{Name: "latitude", Value: sensorSetData.Latitude},
-			{Name: "longitude", Value: sensorSetData.Longitude},
-			{Name: "data_source", Value: "open-meteo"},
-			{Name: "last_updated", Value: time.Now().UTC()},
-		}
+	if err := dataset.Table(stagingTable).Inserter().Put(ctx, rows); err != nil {
+		return fmt.Errorf("failed to stream %d hourly records into staging table %s: %w", len(rows), stagingTable, err)
+	}
 
-		job, err := q.Run(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to run hourly insert query: %v", err)
-		}
-		status, err := job.Wait(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to wait for hourly insert job: %v", err)
-		}
-		if err := status.Err(); err != nil {
-			log.Printf("BigQuery hourly job failed: %v", err)
-			return fmt.Errorf("bigquery hourly job failed: %v", err)
-		}
+	job, err := client.Query(hourlyMergeSQL(projectID, stagingTable)).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to run hourly merge: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for hourly merge job: %w", err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("hourly merge job failed: %w", err)
 	}
 
-	log.Printf("INFO: Successfully inserted %d hourly weather records.", len(weatherData.Hourly.Time))
+	log.Printf("INFO: Successfully merged %d hourly weather records.", len(rows))
 	return nil
 }
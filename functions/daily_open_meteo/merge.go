@@ -0,0 +1,119 @@
+/*
+daily_open_meteo/merge.go
+
+Staging-table MERGE helpers. Per-row inserts issue one BigQuery job per
+record, which for a multi-day hourly backfill means hundreds of serial jobs
+and avoidable quota pressure. Instead, insertDailyWeatherData and
+insertHourlyWeatherData stream every record for a request into a short-lived
+staging table with a single Inserter.Put call, then run exactly one MERGE
+from that staging table into the historical weather table. The MERGE SQL is
+generated by dailyMergeSQL/hourlyMergeSQL, which take no BigQuery client so
+they can be unit tested directly.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// nonAlphanumeric matches any run of characters not legal in a BigQuery
+// table name, used to build a safe staging table name from a sensor_set_id.
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// stagingTableName returns a unique, BigQuery-safe table name for a staging
+// table scoped to one request, so concurrent requests for different (or the
+// same) sensor_set_id never collide.
+func stagingTableName(prefix, sensorSetID string) string {
+	safeID := nonAlphanumeric.ReplaceAllString(sensorSetID, "_")
+	return fmt.Sprintf("%s_staging_%s_%d", prefix, safeID, time.Now().UnixNano())
+}
+
+// dailyMergeSQL returns the MERGE statement that upserts every row of
+// stagingTable into daily_historical_weather, keyed on (date, sensor_set_id).
+// Columns a provider may not report (see WeatherRecord) use COALESCE(S.x, T.x)
+// on update, so a NULL staged value leaves the existing column alone instead
+// of clobbering it - otherwise re-running a date range through a provider
+// that only covers a subset of columns (e.g. METAR) would erase data an
+// earlier, more complete provider had already written.
+func dailyMergeSQL(projectID, stagingTable string) string {
+	return fmt.Sprintf(`
+		MERGE `+"`%[1]s.sunlight_data.daily_historical_weather`"+` T
+		USING `+"`%[1]s.sunlight_data.%[2]s`"+` S
+		ON T.date = S.date AND T.sensor_set_id = S.sensor_set_id
+		WHEN MATCHED THEN
+			UPDATE SET
+				sunrise = COALESCE(S.sunrise, T.sunrise),
+				sunset = COALESCE(S.sunset, T.sunset),
+				daylight_duration = COALESCE(S.daylight_duration, T.daylight_duration),
+				sunshine_duration = COALESCE(S.sunshine_duration, T.sunshine_duration),
+				temperature_2m_max = S.temperature_2m_max,
+				temperature_2m_min = S.temperature_2m_min,
+				uv_index_max = COALESCE(S.uv_index_max, T.uv_index_max),
+				uv_index_clear_sky_max = COALESCE(S.uv_index_clear_sky_max, T.uv_index_clear_sky_max),
+				rain_sum = COALESCE(S.rain_sum, T.rain_sum),
+				showers_sum = COALESCE(S.showers_sum, T.showers_sum),
+				precipitation_sum = COALESCE(S.precipitation_sum, T.precipitation_sum),
+				snowfall_sum = COALESCE(S.snowfall_sum, T.snowfall_sum),
+				precipitation_hours = COALESCE(S.precipitation_hours, T.precipitation_hours),
+				data_source = S.data_source,
+				timezone = S.timezone,
+				latitude = S.latitude,
+				longitude = S.longitude,
+				last_updated = S.last_updated,
+				solar_noon = S.solar_noon,
+				civil_twilight_begin = S.civil_twilight_begin,
+				civil_twilight_end = S.civil_twilight_end,
+				nautical_twilight_begin = S.nautical_twilight_begin,
+				nautical_twilight_end = S.nautical_twilight_end,
+				astronomical_twilight_begin = S.astronomical_twilight_begin,
+				astronomical_twilight_end = S.astronomical_twilight_end,
+				growing_degree_days = S.growing_degree_days,
+				cloud_cover_mean = S.cloud_cover_mean,
+				sky_condition = S.sky_condition
+		WHEN NOT MATCHED THEN
+			INSERT (date, sunrise, sunset, daylight_duration, sunshine_duration, temperature_2m_max, temperature_2m_min, uv_index_max, uv_index_clear_sky_max, rain_sum, showers_sum, precipitation_sum, snowfall_sum, precipitation_hours, data_source, sensor_set_id, timezone, latitude, longitude, last_updated, solar_noon, civil_twilight_begin, civil_twilight_end, nautical_twilight_begin, nautical_twilight_end, astronomical_twilight_begin, astronomical_twilight_end, growing_degree_days, cloud_cover_mean, sky_condition)
+			VALUES(date, sunrise, sunset, daylight_duration, sunshine_duration, temperature_2m_max, temperature_2m_min, uv_index_max, uv_index_clear_sky_max, rain_sum, showers_sum, precipitation_sum, snowfall_sum, precipitation_hours, data_source, sensor_set_id, timezone, latitude, longitude, last_updated, solar_noon, civil_twilight_begin, civil_twilight_end, nautical_twilight_begin, nautical_twilight_end, astronomical_twilight_begin, astronomical_twilight_end, growing_degree_days, cloud_cover_mean, sky_condition)
+	`, projectID, stagingTable)
+}
+
+// hourlyMergeSQL returns the MERGE statement that upserts every row of
+// stagingTable into hourly_historical_weather, keyed on (time, sensor_set_id).
+// As in dailyMergeSQL, columns a provider may not report use
+// COALESCE(S.x, T.x) on update so a NULL staged value doesn't clobber an
+// existing value written by a more complete provider.
+func hourlyMergeSQL(projectID, stagingTable string) string {
+	return fmt.Sprintf(`
+		MERGE `+"`%[1]s.sunlight_data.hourly_historical_weather`"+` T
+		USING `+"`%[1]s.sunlight_data.%[2]s`"+` S
+		ON T.time = S.time AND T.sensor_set_id = S.sensor_set_id
+		WHEN MATCHED THEN
+			UPDATE SET
+				temperature_2m = S.temperature_2m,
+				precipitation = COALESCE(S.precipitation, T.precipitation),
+				relative_humidity_2m = COALESCE(S.relative_humidity_2m, T.relative_humidity_2m),
+				cloud_cover = S.cloud_cover,
+				visibility = COALESCE(S.visibility, T.visibility),
+				soil_temperature_0cm = COALESCE(S.soil_temperature_0cm, T.soil_temperature_0cm),
+				soil_moisture_1_to_3cm = COALESCE(S.soil_moisture_1_to_3cm, T.soil_moisture_1_to_3cm),
+				uv_index = COALESCE(S.uv_index, T.uv_index),
+				uv_index_clear_sky = COALESCE(S.uv_index_clear_sky, T.uv_index_clear_sky),
+				shortwave_radiation = COALESCE(S.shortwave_radiation, T.shortwave_radiation),
+				direct_radiation = COALESCE(S.direct_radiation, T.direct_radiation),
+				wind_speed_10m = S.wind_speed_10m,
+				timezone = S.timezone,
+				latitude = S.latitude,
+				longitude = S.longitude,
+				data_source = S.data_source,
+				last_updated = S.last_updated
+		WHEN NOT MATCHED THEN
+			INSERT (time, sensor_set_id, temperature_2m, precipitation, relative_humidity_2m, cloud_cover, visibility, soil_temperature_0cm, soil_moisture_1_to_3cm, uv_index, uv_index_clear_sky, shortwave_radiation, direct_radiation, wind_speed_10m, timezone, latitude, longitude, data_source, last_updated)
+			VALUES(time, sensor_set_id, temperature_2m, precipitation, relative_humidity_2m, cloud_cover, visibility, soil_temperature_0cm, soil_moisture_1_to_3cm, uv_index, uv_index_clear_sky, shortwave_radiation, direct_radiation, wind_speed_10m, timezone, latitude, longitude, data_source, last_updated)
+	`, projectID, stagingTable)
+}
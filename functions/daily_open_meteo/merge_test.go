@@ -0,0 +1,90 @@
+/*
+daily_open_meteo/merge_test.go
+
+Tests for the staging-table MERGE SQL helpers.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDailyMergeSQL_KeyedOnDateAndSensorSet verifies the generated daily
+// MERGE statement reads from the given staging table and is keyed on
+// (date, sensor_set_id), so repeated merges of the same staging data are
+// idempotent.
+func TestDailyMergeSQL_KeyedOnDateAndSensorSet(t *testing.T) {
+	sql := dailyMergeSQL("my-project", "daily_weather_staging_abc_123")
+
+	for _, want := range []string{
+		"`my-project.sunlight_data.daily_historical_weather`",
+		"`my-project.sunlight_data.daily_weather_staging_abc_123`",
+		"ON T.date = S.date AND T.sensor_set_id = S.sensor_set_id",
+		"WHEN MATCHED THEN",
+		"WHEN NOT MATCHED THEN",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("dailyMergeSQL output missing %q:\n%s", want, sql)
+		}
+	}
+}
+
+// TestHourlyMergeSQL_KeyedOnTimeAndSensorSet verifies the generated hourly
+// MERGE statement reads from the given staging table and is keyed on
+// (time, sensor_set_id).
+func TestHourlyMergeSQL_KeyedOnTimeAndSensorSet(t *testing.T) {
+	sql := hourlyMergeSQL("my-project", "hourly_weather_staging_abc_123")
+
+	for _, want := range []string{
+		"`my-project.sunlight_data.hourly_historical_weather`",
+		"`my-project.sunlight_data.hourly_weather_staging_abc_123`",
+		"ON T.time = S.time AND T.sensor_set_id = S.sensor_set_id",
+		"WHEN MATCHED THEN",
+		"WHEN NOT MATCHED THEN",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("hourlyMergeSQL output missing %q:\n%s", want, sql)
+		}
+	}
+}
+
+// TestDailyMergeSQL_CoversEveryWeatherRecordColumn guards against a column
+// being added to WeatherRecord (and so to the staging table, via
+// bigquery.InferSchema) without also being wired into the MERGE, which would
+// silently drop that column's data when the staging table is cleaned up.
+func TestDailyMergeSQL_CoversEveryWeatherRecordColumn(t *testing.T) {
+	sql := dailyMergeSQL("my-project", "daily_weather_staging_abc_123")
+
+	recordType := reflect.TypeOf(WeatherRecord{})
+	for i := 0; i < recordType.NumField(); i++ {
+		column := recordType.Field(i).Tag.Get("bigquery")
+		if column == "" {
+			continue
+		}
+		if !strings.Contains(sql, column) {
+			t.Errorf("dailyMergeSQL output missing column %q from WeatherRecord", column)
+		}
+	}
+}
+
+// TestStagingTableName_SanitizesSensorSetID verifies that sensor_set_ids
+// containing characters illegal in a BigQuery table name (e.g. hyphens) are
+// sanitized, and that two calls for the same sensor_set_id never collide.
+func TestStagingTableName_SanitizesSensorSetID(t *testing.T) {
+	name := stagingTableName("daily_weather", "roof-east-01")
+	if strings.Contains(name, "-") {
+		t.Errorf("expected stagingTableName to sanitize hyphens, got %q", name)
+	}
+
+	other := stagingTableName("daily_weather", "roof-east-01")
+	if name == other {
+		t.Errorf("expected successive calls to produce unique staging table names, got %q twice", name)
+	}
+}
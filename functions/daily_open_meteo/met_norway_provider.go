@@ -0,0 +1,169 @@
+/*
+daily_open_meteo/met_norway_provider.go
+
+METNorwayProvider implements WeatherProvider against the Norwegian
+Meteorological Institute's Locationforecast API. Used as a fallback, or as
+the authoritative source for sensors in the Nordics where MET Norway's
+modeling is generally better than Open-Meteo's.
+
+MET Norway's terms of service require a descriptive User-Agent identifying
+the application and a contact method; see
+https://api.met.no/doc/TermsOfService
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// metNorwayUserAgent identifies this application to api.met.no, as their
+// terms of service require. Update the contact address if it changes.
+const metNorwayUserAgent = "sunlight_sensor_gcp/1.0 github.com/kden/sunlight_sensor_gcp"
+
+// METNorwayProvider fetches weather data from api.met.no's Locationforecast
+// compact endpoint. Locationforecast is a forecast API, so only the
+// timeseries entries that fall within [startDate, endDate] are kept; callers
+// backfilling far into the past will get an empty result.
+type METNorwayProvider struct{}
+
+// metNorwayResponse is the subset of the Locationforecast compact response
+// this provider consumes.
+type metNorwayResponse struct {
+	Properties struct {
+		Timeseries []struct {
+			Time string `json:"time"`
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature    float64 `json:"air_temperature"`
+						RelativeHumidity  float64 `json:"relative_humidity"`
+						CloudAreaFraction float64 `json:"cloud_area_fraction"`
+						WindSpeed         float64 `json:"wind_speed"`
+					} `json:"details"`
+				} `json:"instant"`
+				Next1Hours struct {
+					Summary struct {
+						SymbolCode string `json:"symbol_code"`
+					} `json:"summary"`
+					Details struct {
+						PrecipitationAmount float64 `json:"precipitation_amount"`
+					} `json:"details"`
+				} `json:"next_1_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+func (p *METNorwayProvider) Name() string {
+	return "met-norway"
+}
+
+func (p *METNorwayProvider) fetch(ctx context.Context, sensorSet *SensorSet) (*metNorwayResponse, error) {
+	url := fmt.Sprintf(
+		"https://api.met.no/weatherapi/locationforecast/2.0/compact?lat=%f&lon=%f",
+		sensorSet.Latitude, sensorSet.Longitude,
+	)
+	log.Printf("INFO: Calling MET Norway Locationforecast API: %s", url)
+
+	var resp metNorwayResponse
+	headers := map[string]string{"User-Agent": metNorwayUserAgent}
+	if err := getJSON(ctx, url, headers, &resp); err != nil {
+		weatherProviderHTTPErrors.WithLabelValues(p.Name()).Inc()
+		return nil, fmt.Errorf("met-norway fetch: %w", err)
+	}
+	return &resp, nil
+}
+
+func (p *METNorwayProvider) FetchHourly(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]HourlyWeatherRecord, error) {
+	start, end, err := parseDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.fetch(ctx, sensorSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []HourlyWeatherRecord
+	for _, ts := range resp.Properties.Timeseries {
+		t, err := time.Parse(time.RFC3339, ts.Time)
+		if err != nil {
+			log.Printf("ERROR: Failed to parse MET Norway timestamp '%s': %v", ts.Time, err)
+			continue
+		}
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		records = append(records, HourlyWeatherRecord{
+			Time:               t,
+			Temperature2m:      ts.Data.Instant.Details.AirTemperature,
+			Precipitation:      validFloat64(ts.Data.Next1Hours.Details.PrecipitationAmount),
+			RelativeHumidity2m: validFloat64(ts.Data.Instant.Details.RelativeHumidity),
+			CloudCover:         ts.Data.Instant.Details.CloudAreaFraction,
+			// MET Norway reports wind speed in m/s; Open-Meteo's column is km/h.
+			WindSpeed10m: ts.Data.Instant.Details.WindSpeed * 3.6,
+		})
+	}
+
+	log.Printf("INFO: MET Norway returned %d hourly records in range.", len(records))
+	return records, nil
+}
+
+func (p *METNorwayProvider) FetchDaily(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]WeatherRecord, error) {
+	hourly, err := p.FetchHourly(ctx, sensorSet, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	records := aggregateMETNorwayDaily(hourly)
+	log.Printf("INFO: MET Norway aggregated %d daily records from %d hourly entries.", len(records), len(hourly))
+	return records, nil
+}
+
+// aggregateMETNorwayDaily reduces hourly records to one WeatherRecord per
+// date, taking the min/max temperature and summing precipitation over each
+// day's hours. Kept separate from FetchDaily so it can be unit tested without
+// a network call.
+func aggregateMETNorwayDaily(hourly []HourlyWeatherRecord) []WeatherRecord {
+	type aggregate struct {
+		tMax, tMin    float64
+		precipitation float64
+	}
+	byDate := make(map[string]*aggregate)
+	var order []string
+
+	for _, h := range hourly {
+		date := h.Time.Format("2006-01-02")
+		agg, ok := byDate[date]
+		if !ok {
+			agg = &aggregate{tMax: -math.MaxFloat64, tMin: math.MaxFloat64}
+			byDate[date] = agg
+			order = append(order, date)
+		}
+		agg.tMax = math.Max(agg.tMax, h.Temperature2m)
+		agg.tMin = math.Min(agg.tMin, h.Temperature2m)
+		agg.precipitation += h.Precipitation.Float64
+	}
+
+	records := make([]WeatherRecord, 0, len(order))
+	for _, date := range order {
+		agg := byDate[date]
+		records = append(records, WeatherRecord{
+			Date:             date,
+			Temperature2mMax: agg.tMax,
+			Temperature2mMin: agg.tMin,
+			PrecipitationSum: validFloat64(agg.precipitation),
+		})
+	}
+	return records
+}
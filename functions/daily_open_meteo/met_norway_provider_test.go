@@ -0,0 +1,42 @@
+/*
+daily_open_meteo/met_norway_provider_test.go
+
+Tests for METNorwayProvider's hourly-to-daily aggregation.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregateMETNorwayDaily(t *testing.T) {
+	hourly := []HourlyWeatherRecord{
+		{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Temperature2m: 8, Precipitation: validFloat64(0.5)},
+		{Time: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), Temperature2m: 19, Precipitation: validFloat64(1.5)},
+		{Time: time.Date(2024, 6, 2, 6, 0, 0, 0, time.UTC), Temperature2m: 3, Precipitation: validFloat64(0)},
+	}
+
+	records := aggregateMETNorwayDaily(hourly)
+	if len(records) != 2 {
+		t.Fatalf("aggregateMETNorwayDaily() returned %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if first.Date != "2024-06-01" || first.Temperature2mMax != 19 || first.Temperature2mMin != 8 {
+		t.Errorf("2024-06-01 record = %+v, want date=2024-06-01 max=19 min=8", first)
+	}
+	if !first.PrecipitationSum.Valid || first.PrecipitationSum.Float64 != 2 {
+		t.Errorf("2024-06-01 PrecipitationSum = %+v, want valid 2 (0.5+1.5 summed)", first.PrecipitationSum)
+	}
+
+	second := records[1]
+	if second.Date != "2024-06-02" || second.Temperature2mMax != 3 || second.Temperature2mMin != 3 {
+		t.Errorf("2024-06-02 record = %+v, want date=2024-06-02 max=3 min=3", second)
+	}
+}
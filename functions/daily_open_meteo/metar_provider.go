@@ -0,0 +1,200 @@
+/*
+daily_open_meteo/metar_provider.go
+
+METARProvider implements WeatherProvider against NOAA's Aviation Weather
+Center text data server, which reports observed (not modeled) conditions
+from airport weather stations as METAR. Useful as an authoritative ground-
+truth source for sensors sited near an airport; requires a `station_code`
+column on sensor_set (e.g. "KSEA").
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+)
+
+// METARProvider fetches recent observations from NOAA's ADDS METAR XML
+// feed, keyed by the airport station code on the sensor_set row.
+type METARProvider struct{}
+
+// metarResponse is the subset of the ADDS dataserver XML response this
+// provider consumes.
+type metarResponse struct {
+	XMLName xml.Name `xml:"response"`
+	Data    struct {
+		METARs []metarObservation `xml:"METAR"`
+	} `xml:"data"`
+}
+
+type metarObservation struct {
+	StationID       string  `xml:"station_id"`
+	ObservationTime string  `xml:"observation_time"`
+	TempC           float64 `xml:"temp_c"`
+	WindSpeedKt     float64 `xml:"wind_speed_kt"`
+	VisibilityMi    float64 `xml:"visibility_statute_mi"`
+	SkyConditions   []struct {
+		SkyCover string `xml:"sky_cover,attr"`
+	} `xml:"sky_condition"`
+}
+
+// cloudCoverPercent approximates a cloud_cover percentage from the most
+// overcast sky_condition layer reported, using the standard METAR cover
+// codes (NOAA Aviation Weather Center METAR field reference).
+func cloudCoverPercent(conditions []struct {
+	SkyCover string `xml:"sky_cover,attr"`
+}) float64 {
+	worst := 0.0
+	for _, c := range conditions {
+		var pct float64
+		switch c.SkyCover {
+		case "SKC", "CLR", "NSC":
+			pct = 0
+		case "FEW":
+			pct = 20
+		case "SCT":
+			pct = 40
+		case "BKN":
+			pct = 75
+		case "OVC", "VV":
+			pct = 100
+		default:
+			continue
+		}
+		worst = math.Max(worst, pct)
+	}
+	return worst
+}
+
+func (p *METARProvider) Name() string {
+	return "metar"
+}
+
+func (p *METARProvider) fetch(ctx context.Context, sensorSet *SensorSet) ([]metarObservation, error) {
+	if sensorSet.StationCode == "" {
+		return nil, fmt.Errorf("metar provider requires a station_code on sensor_set")
+	}
+
+	url := fmt.Sprintf(
+		"https://aviationweather.gov/adds/dataserver_current/httpparam?dataSource=metars&requestType=retrieve&format=xml&stationString=%s&hoursBeforeNow=72",
+		sensorSet.StationCode,
+	)
+	log.Printf("INFO: Calling NOAA ADDS METAR feed: %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		weatherProviderHTTPErrors.WithLabelValues(p.Name()).Inc()
+		return nil, fmt.Errorf("metar fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		weatherProviderHTTPErrors.WithLabelValues(p.Name()).Inc()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var parsed metarResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode METAR XML response: %w", err)
+	}
+
+	log.Printf("INFO: NOAA ADDS returned %d METAR observations for %s.", len(parsed.Data.METARs), sensorSet.StationCode)
+	return parsed.Data.METARs, nil
+}
+
+func (p *METARProvider) FetchHourly(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]HourlyWeatherRecord, error) {
+	start, end, err := parseDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	observations, err := p.fetch(ctx, sensorSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []HourlyWeatherRecord
+	for _, o := range observations {
+		t, err := time.Parse(time.RFC3339, o.ObservationTime)
+		if err != nil {
+			log.Printf("ERROR: Failed to parse METAR observation_time '%s': %v", o.ObservationTime, err)
+			continue
+		}
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		records = append(records, HourlyWeatherRecord{
+			Time:          t,
+			Temperature2m: o.TempC,
+			// Open-Meteo reports visibility in meters and wind speed in km/h;
+			// normalize METAR's statute miles and knots to match.
+			Visibility:   validFloat64(o.VisibilityMi * 1609.34),
+			WindSpeed10m: o.WindSpeedKt * 1.852,
+			CloudCover:   cloudCoverPercent(o.SkyConditions),
+		})
+	}
+
+	log.Printf("INFO: METAR returned %d hourly records in range for %s.", len(records), sensorSet.StationCode)
+	return records, nil
+}
+
+func (p *METARProvider) FetchDaily(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]WeatherRecord, error) {
+	hourly, err := p.FetchHourly(ctx, sensorSet, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	records := aggregateMETARDaily(hourly)
+	log.Printf("INFO: METAR aggregated %d daily records from %d hourly observations.", len(records), len(hourly))
+	return records, nil
+}
+
+// aggregateMETARDaily reduces hourly observations to one WeatherRecord per
+// date, taking the min/max temperature over each day's observations. Kept
+// separate from FetchDaily so it can be unit tested without a network call.
+func aggregateMETARDaily(hourly []HourlyWeatherRecord) []WeatherRecord {
+	type aggregate struct {
+		tMax, tMin float64
+	}
+	byDate := make(map[string]*aggregate)
+	var order []string
+
+	for _, h := range hourly {
+		date := h.Time.Format("2006-01-02")
+		agg, ok := byDate[date]
+		if !ok {
+			agg = &aggregate{tMax: -math.MaxFloat64, tMin: math.MaxFloat64}
+			byDate[date] = agg
+			order = append(order, date)
+		}
+		agg.tMax = math.Max(agg.tMax, h.Temperature2m)
+		agg.tMin = math.Min(agg.tMin, h.Temperature2m)
+	}
+
+	records := make([]WeatherRecord, 0, len(order))
+	for _, date := range order {
+		agg := byDate[date]
+		records = append(records, WeatherRecord{
+			Date:             date,
+			Temperature2mMax: agg.tMax,
+			Temperature2mMin: agg.tMin,
+		})
+	}
+	return records
+}
@@ -0,0 +1,69 @@
+/*
+daily_open_meteo/metar_provider_test.go
+
+Tests for METARProvider's sky-cover mapping and hourly-to-daily aggregation.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"testing"
+	"time"
+)
+
+// skyCover is a type alias (not a distinct named type) for the anonymous
+// struct cloudCoverPercent accepts, so literals built from it can be passed
+// straight through without a conversion.
+type skyCover = struct {
+	SkyCover string `xml:"sky_cover,attr"`
+}
+
+func TestCloudCoverPercent(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []skyCover
+		want       float64
+	}{
+		{"no layers reported", nil, 0},
+		{"clear sky", []skyCover{{"SKC"}}, 0},
+		{"few clouds", []skyCover{{"FEW"}}, 20},
+		{"scattered clouds", []skyCover{{"SCT"}}, 40},
+		{"broken clouds", []skyCover{{"BKN"}}, 75},
+		{"overcast", []skyCover{{"OVC"}}, 100},
+		{"vertical visibility treated as overcast", []skyCover{{"VV"}}, 100},
+		{"unrecognized code is ignored", []skyCover{{"???"}}, 0},
+		{"worst (most overcast) layer wins", []skyCover{{"FEW"}, {"BKN"}, {"SCT"}}, 75},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cloudCoverPercent(tc.conditions); got != tc.want {
+				t.Errorf("cloudCoverPercent(%v) = %v, want %v", tc.conditions, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAggregateMETARDaily(t *testing.T) {
+	hourly := []HourlyWeatherRecord{
+		{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Temperature2m: 10},
+		{Time: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), Temperature2m: 22},
+		{Time: time.Date(2024, 6, 2, 6, 0, 0, 0, time.UTC), Temperature2m: 5},
+	}
+
+	records := aggregateMETARDaily(hourly)
+	if len(records) != 2 {
+		t.Fatalf("aggregateMETARDaily() returned %d records, want 2", len(records))
+	}
+
+	if records[0].Date != "2024-06-01" || records[0].Temperature2mMax != 22 || records[0].Temperature2mMin != 10 {
+		t.Errorf("2024-06-01 record = %+v, want date=2024-06-01 max=22 min=10", records[0])
+	}
+	if records[1].Date != "2024-06-02" || records[1].Temperature2mMax != 5 || records[1].Temperature2mMin != 5 {
+		t.Errorf("2024-06-02 record = %+v, want date=2024-06-02 max=5 min=5", records[1])
+	}
+}
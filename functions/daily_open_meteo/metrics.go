@@ -0,0 +1,63 @@
+/*
+daily_open_meteo/metrics.go
+
+Prometheus/OpenMetrics instrumentation for the module, exposed via a
+companion Metrics Cloud Function so an external scraper (or `curl` during
+an incident) can see fetch/merge health without reading Cloud Logging.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"time"
+
+	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func init() {
+	functions.HTTP("Metrics", promhttp.Handler().ServeHTTP)
+}
+
+var (
+	// weatherFetchTotal counts every provider fetch attempt DailyWeatherer
+	// makes, labeled by outcome so a dashboard can alert on a rising
+	// error ratio for a specific provider or sensor_set.
+	weatherFetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_fetch_total",
+		Help: "Weather provider fetch attempts, labeled by provider, sensor_set, and status (ok|error).",
+	}, []string{"provider", "sensor_set", "status"})
+
+	// weatherBigQueryMergeDuration times the stage-and-merge round trip in
+	// insertDailyWeatherData / insertHourlyWeatherData.
+	weatherBigQueryMergeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "weather_bigquery_merge_duration_seconds",
+		Help:    "Time to stage and merge weather records into a BigQuery historical weather table.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"table"})
+
+	// weatherProviderHTTPErrors counts non-2xx responses and network
+	// errors talking to an upstream weather provider. For the JSON-based
+	// providers (Open-Meteo, MET Norway) it also counts responses whose body
+	// fails to decode.
+	weatherProviderHTTPErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_provider_http_errors_total",
+		Help: "Non-2xx responses, network errors and (for JSON providers) undecodable responses from a weather provider's API.",
+	}, []string{"provider"})
+)
+
+// prometheusTimer starts a stopwatch and returns a func that records the
+// elapsed time against observer when called, meant to be used as
+// `defer prometheusTimer(observer)()`.
+func prometheusTimer(observer prometheus.Observer) func() {
+	start := time.Now()
+	return func() {
+		observer.Observe(time.Since(start).Seconds())
+	}
+}
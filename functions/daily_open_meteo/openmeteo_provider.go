@@ -0,0 +1,182 @@
+/*
+daily_open_meteo/openmeteo_provider.go
+
+OpenMeteoProvider implements WeatherProvider against the free Open-Meteo
+forecast API. This is the original, default data source for the module;
+its JSON fields and timestamp parsing have moved here from function.go as
+part of the WeatherProvider refactor. Daily and hourly data are now fetched
+with two separate requests, and measurements are stored as nullable values.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// OpenMeteoProvider fetches historical/forecast weather data from
+// api.open-meteo.com. It requires no authentication.
+type OpenMeteoProvider struct{}
+
+// openMeteoDailyResponse represents the `daily` portion of the JSON
+// response from the Open-Meteo API.
+type openMeteoDailyResponse struct {
+	Daily struct {
+		Time               []string  `json:"time"`
+		Sunrise            []string  `json:"sunrise"`
+		Sunset             []string  `json:"sunset"`
+		DaylightDuration   []float64 `json:"daylight_duration"`
+		SunshineDuration   []float64 `json:"sunshine_duration"`
+		Temperature2mMax   []float64 `json:"temperature_2m_max"`
+		Temperature2mMin   []float64 `json:"temperature_2m_min"`
+		UvIndexMax         []float64 `json:"uv_index_max"`
+		UvIndexClearSkyMax []float64 `json:"uv_index_clear_sky_max"`
+		RainSum            []float64 `json:"rain_sum"`
+		ShowersSum         []float64 `json:"showers_sum"`
+		PrecipitationSum   []float64 `json:"precipitation_sum"`
+		SnowfallSum        []float64 `json:"snowfall_sum"`
+		PrecipitationHours []float64 `json:"precipitation_hours"`
+	} `json:"daily"`
+}
+
+// openMeteoHourlyResponse represents the `hourly` portion of the JSON
+// response from the Open-Meteo API.
+type openMeteoHourlyResponse struct {
+	Hourly struct {
+		Time               []string  `json:"time"`
+		Temperature2m      []float64 `json:"temperature_2m"`
+		Precipitation      []float64 `json:"precipitation"`
+		RelativeHumidity2m []float64 `json:"relative_humidity_2m"`
+		CloudCover         []float64 `json:"cloud_cover"`
+		Visibility         []float64 `json:"visibility"`
+		SoilTemperature0cm []float64 `json:"soil_temperature_0cm"`
+		SoilMoisture1To3cm []float64 `json:"soil_moisture_1_to_3cm"`
+		UvIndex            []float64 `json:"uv_index"`
+		UvIndexClearSky    []float64 `json:"uv_index_clear_sky"`
+		ShortwaveRadiation []float64 `json:"shortwave_radiation"`
+		DirectRadiation    []float64 `json:"direct_radiation"`
+		WindSpeed10m       []float64 `json:"wind_speed_10m"`
+	} `json:"hourly"`
+}
+
+func (p *OpenMeteoProvider) Name() string {
+	return "open-meteo"
+}
+
+func (p *OpenMeteoProvider) FetchDaily(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]WeatherRecord, error) {
+	url := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&daily=sunrise,sunset,daylight_duration,sunshine_duration,temperature_2m_max,temperature_2m_min,uv_index_max,uv_index_clear_sky_max,rain_sum,showers_sum,precipitation_sum,snowfall_sum,precipitation_hours&start_date=%s&end_date=%s",
+		sensorSet.Latitude, sensorSet.Longitude, startDate, endDate,
+	)
+	log.Printf("INFO: Calling Open-Meteo daily API: %s", url)
+
+	var resp openMeteoDailyResponse
+	if err := getJSON(ctx, url, nil, &resp); err != nil {
+		weatherProviderHTTPErrors.WithLabelValues(p.Name()).Inc()
+		return nil, fmt.Errorf("open-meteo daily fetch: %w", err)
+	}
+
+	records := make([]WeatherRecord, 0, len(resp.Daily.Time))
+	for i, t := range resp.Daily.Time {
+		sunrise, _ := time.Parse("2006-01-02T15:04", resp.Daily.Sunrise[i])
+		sunset, _ := time.Parse("2006-01-02T15:04", resp.Daily.Sunset[i])
+		records = append(records, WeatherRecord{
+			Date:               t,
+			Sunrise:            validTimestamp(sunrise),
+			Sunset:             validTimestamp(sunset),
+			DaylightDuration:   validFloat64(resp.Daily.DaylightDuration[i]),
+			SunshineDuration:   validFloat64(resp.Daily.SunshineDuration[i]),
+			Temperature2mMax:   resp.Daily.Temperature2mMax[i],
+			Temperature2mMin:   resp.Daily.Temperature2mMin[i],
+			UvIndexMax:         validFloat64(resp.Daily.UvIndexMax[i]),
+			UvIndexClearSkyMax: validFloat64(resp.Daily.UvIndexClearSkyMax[i]),
+			RainSum:            validFloat64(resp.Daily.RainSum[i]),
+			ShowersSum:         validFloat64(resp.Daily.ShowersSum[i]),
+			PrecipitationSum:   validFloat64(resp.Daily.PrecipitationSum[i]),
+			SnowfallSum:        validFloat64(resp.Daily.SnowfallSum[i]),
+			PrecipitationHours: validFloat64(resp.Daily.PrecipitationHours[i]),
+		})
+	}
+
+	log.Printf("INFO: Open-Meteo returned %d daily records.", len(records))
+	return records, nil
+}
+
+func (p *OpenMeteoProvider) FetchHourly(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]HourlyWeatherRecord, error) {
+	url := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&hourly=temperature_2m,precipitation,relative_humidity_2m,cloud_cover,visibility,soil_temperature_0cm,soil_moisture_1_to_3cm,uv_index,uv_index_clear_sky,shortwave_radiation,direct_radiation,wind_speed_10m&start_date=%s&end_date=%s",
+		sensorSet.Latitude, sensorSet.Longitude, startDate, endDate,
+	)
+	log.Printf("INFO: Calling Open-Meteo hourly API: %s", url)
+
+	var resp openMeteoHourlyResponse
+	if err := getJSON(ctx, url, nil, &resp); err != nil {
+		weatherProviderHTTPErrors.WithLabelValues(p.Name()).Inc()
+		return nil, fmt.Errorf("open-meteo hourly fetch: %w", err)
+	}
+
+	records := make([]HourlyWeatherRecord, 0, len(resp.Hourly.Time))
+	for i, timeStr := range resp.Hourly.Time {
+		hourlyTime, err := time.Parse("2006-01-02T15:04", timeStr)
+		if err != nil {
+			log.Printf("ERROR: Failed to parse hourly timestamp '%s': %v", timeStr, err)
+			continue
+		}
+		records = append(records, HourlyWeatherRecord{
+			Time:               hourlyTime,
+			Temperature2m:      resp.Hourly.Temperature2m[i],
+			Precipitation:      validFloat64(resp.Hourly.Precipitation[i]),
+			RelativeHumidity2m: validFloat64(resp.Hourly.RelativeHumidity2m[i]),
+			CloudCover:         resp.Hourly.CloudCover[i],
+			Visibility:         validFloat64(resp.Hourly.Visibility[i]),
+			SoilTemperature0cm: validFloat64(resp.Hourly.SoilTemperature0cm[i]),
+			SoilMoisture1To3cm: validFloat64(resp.Hourly.SoilMoisture1To3cm[i]),
+			UvIndex:            validFloat64(resp.Hourly.UvIndex[i]),
+			UvIndexClearSky:    validFloat64(resp.Hourly.UvIndexClearSky[i]),
+			ShortwaveRadiation: validFloat64(resp.Hourly.ShortwaveRadiation[i]),
+			DirectRadiation:    validFloat64(resp.Hourly.DirectRadiation[i]),
+			WindSpeed10m:       resp.Hourly.WindSpeed10m[i],
+		})
+	}
+
+	log.Printf("INFO: Open-Meteo returned %d hourly records.", len(records))
+	return records, nil
+}
+
+// getJSON issues a GET request with optional headers and decodes the JSON
+// response body into out. It is shared by the HTTP-backed providers.
+func getJSON(ctx context.Context, url string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &httpStatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+daily_open_meteo/provider.go
+
+WeatherProvider abstraction so DailyWeatherer can fetch historical weather
+data from more than one upstream source. Open-Meteo remains the default;
+MET Norway and METAR are available per sensor_set via the `provider` column
+or a `?provider=` query override.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// WeatherProvider fetches historical daily and hourly weather observations
+// for a sensor location and normalizes them to the schema used by the
+// daily_historical_weather and hourly_historical_weather BigQuery tables.
+//
+// Implementations populate only the measurement columns of WeatherRecord
+// and HourlyWeatherRecord. The caller (DailyWeatherer / insertDailyWeatherData
+// / insertHourlyWeatherData) is responsible for stamping sensor_set_id,
+// timezone, latitude, longitude, data_source, and last_updated once a
+// provider has returned.
+type WeatherProvider interface {
+	// Name identifies the provider and is stamped into the data_source column.
+	Name() string
+	FetchDaily(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]WeatherRecord, error)
+	FetchHourly(ctx context.Context, sensorSet *SensorSet, startDate, endDate string) ([]HourlyWeatherRecord, error)
+}
+
+// ErrUnknownProvider is returned by newProvider when the requested provider
+// name does not match a registered WeatherProvider.
+var ErrUnknownProvider = errors.New("unknown weather provider")
+
+// newProvider returns the WeatherProvider registered under name, defaulting
+// to Open-Meteo when name is empty so existing sensor_set rows without a
+// provider column keep working unchanged.
+func newProvider(name string) (WeatherProvider, error) {
+	switch name {
+	case "", "open-meteo":
+		return &OpenMeteoProvider{}, nil
+	case "met-norway":
+		return &METNorwayProvider{}, nil
+	case "metar":
+		return &METARProvider{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+}
+
+// resolveProvider picks the provider for a request: an explicit
+// ?provider= query override takes precedence over the sensor_set's own
+// provider column.
+func resolveProvider(queryOverride string, sensorSetData *SensorSet) (WeatherProvider, error) {
+	name := sensorSetData.Provider
+	if queryOverride != "" {
+		name = queryOverride
+	}
+	return newProvider(name)
+}
@@ -0,0 +1,50 @@
+/*
+daily_open_meteo/ratelimit.go
+
+Per-sensor_set rate limiting in front of DailyWeatherer, so a misconfigured
+Cloud Scheduler job (or a retry storm) cannot exhaust Open-Meteo's fair-use
+quota or burn through BigQuery slots.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/throttled/throttled/v2"
+	"github.com/throttled/throttled/v2/store/memstore"
+)
+
+// sensorSetRateLimiter enforces ~20 requests/minute per sensor_set_id using
+// the GCRA algorithm, with a small burst allowance for backfills that
+// legitimately fire a couple of requests back to back.
+var sensorSetRateLimiter throttled.RateLimiterCtx
+
+func init() {
+	store, err := memstore.NewCtx(65536)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create rate limiter store: %v", err))
+	}
+
+	quota := throttled.RateQuota{MaxRate: throttled.PerMin(20), MaxBurst: 5}
+	limiter, err := throttled.NewGCRARateLimiterCtx(store, quota)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create rate limiter: %v", err))
+	}
+
+	sensorSetRateLimiter = limiter
+}
+
+// allowRequest reports whether a request for sensorSetID is within quota.
+func allowRequest(ctx context.Context, sensorSetID string) (bool, error) {
+	limited, _, err := sensorSetRateLimiter.RateLimitCtx(ctx, sensorSetID, 1)
+	if err != nil {
+		return false, fmt.Errorf("rate limiter check failed: %w", err)
+	}
+	return !limited, nil
+}
@@ -0,0 +1,65 @@
+/*
+daily_open_meteo/retry.go
+
+Exponential backoff with jitter for provider calls made during a backfill,
+where a single run may make thousands of requests and a transient 429/5xx
+from the provider shouldn't fail the whole window.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	retryBaseDelay   = 500 * time.Millisecond
+	retryFactor      = 2.0
+	retryMaxAttempts = 5
+)
+
+// isRetryableErr reports whether err is a provider HTTP error whose status
+// code is 429 or 5xx. Any other error (malformed response, BigQuery error,
+// context cancellation) is treated as permanent.
+func isRetryableErr(err error) bool {
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+}
+
+// withRetry calls fn up to retryMaxAttempts times. Before retry n (n = 1, 2,
+// ...) it waits a random ("full jitter") duration in
+// [0, retryBaseDelay*retryFactor^(n-1)), and it stops early on a
+// non-retryable error or context cancellation.
+func withRetry(ctx context.Context, fn func() error) error {
+	delay := retryBaseDelay
+	var err error
+	for attempt := 1; attempt <= retryMaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if attempt == retryMaxAttempts || !isRetryableErr(err) {
+			return err
+		}
+
+		jittered := time.Duration(rand.Int63n(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jittered):
+		}
+		delay = time.Duration(float64(delay) * retryFactor)
+	}
+	return err
+}
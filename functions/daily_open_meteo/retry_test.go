@@ -0,0 +1,88 @@
+/*
+daily_open_meteo/retry_test.go
+
+Tests for the retry classification logic used by withRetry.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsRetryableErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429 is retryable", &httpStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500 is retryable", &httpStatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"503 is retryable", &httpStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"404 is not retryable", &httpStatusError{StatusCode: http.StatusNotFound}, false},
+		{"wrapped retryable status error is still retryable", fmt.Errorf("fetch failed: %w", &httpStatusError{StatusCode: http.StatusBadGateway}), true},
+		{"non-status error is not retryable", errors.New("malformed response"), false},
+		{"nil error is not retryable", nil, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableErr(tc.err); got != tc.want {
+				t.Errorf("isRetryableErr(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry_StopsOnNonRetryableError(t *testing.T) {
+	attempts := 0
+	err := withRetry(context.Background(), func() error {
+		attempts++
+		return &httpStatusError{StatusCode: http.StatusNotFound}
+	})
+	if err == nil {
+		t.Fatal("expected a non-retryable error to be returned")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (no retries on a non-retryable error)", attempts)
+	}
+}
+
+func TestWithRetry_RetriesUpToMaxAttempts(t *testing.T) {
+	attempts := 0
+	err := withRetry(context.Background(), func() error {
+		attempts++
+		return &httpStatusError{StatusCode: http.StatusServiceUnavailable}
+	})
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if attempts != retryMaxAttempts {
+		t.Errorf("attempts = %d, want %d", attempts, retryMaxAttempts)
+	}
+}
+
+func TestWithRetry_SucceedsAfterTransientError(t *testing.T) {
+	attempts := 0
+	err := withRetry(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return &httpStatusError{StatusCode: http.StatusTooManyRequests}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry() = %v, want nil", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
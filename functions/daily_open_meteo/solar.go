@@ -0,0 +1,168 @@
+/*
+daily_open_meteo/solar.go
+
+Derived astronomical and agronomic fields for daily_historical_weather that
+the weather providers don't return directly: solar noon, civil/nautical/
+astronomical twilight bounds, growing-degree-days, and a categorical
+sky_condition. The solar position math is the standard NOAA fractional-year
+approximation (Spencer 1971), accurate to within a few minutes, which is
+plenty for twilight bounds stored alongside hourly-resolution weather data.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"math"
+	"time"
+)
+
+// growingDegreeDayBaseC is the base temperature used for growing-degree-day
+// accumulation. 10C is the common base for warm-season crops (corn, tomato).
+const growingDegreeDayBaseC = 10.0
+
+// Solar elevation angles, in degrees, at which each event occurs. -0.833
+// accounts for atmospheric refraction and the sun's apparent radius at the
+// horizon; the twilight angles are the standard civil/nautical/astronomical
+// definitions.
+const (
+	sunriseSunsetAltitudeDeg        = -0.833
+	civilTwilightAltitudeDeg        = -6.0
+	nauticalTwilightAltitudeDeg     = -12.0
+	astronomicalTwilightAltitudeDeg = -18.0
+)
+
+// solarDay holds every solar event this package derives for one calendar
+// date at one location. A zero time.Time in any field means the sun never
+// reaches that altitude that day (polar day or polar night).
+type solarDay struct {
+	Noon             time.Time
+	CivilDawn        time.Time
+	CivilDusk        time.Time
+	NauticalDawn     time.Time
+	NauticalDusk     time.Time
+	AstronomicalDawn time.Time
+	AstronomicalDusk time.Time
+}
+
+// computeSolarDay derives solar noon and the civil/nautical/astronomical
+// twilight bounds for date at the given location, in UTC.
+func computeSolarDay(date time.Time, latDeg, lonDeg float64) solarDay {
+	declRad, eqTimeMinutes := solarPosition(date)
+	noonMinutes := 720 - 4*lonDeg - eqTimeMinutes
+
+	day := solarDay{Noon: minutesToUTC(date, noonMinutes)}
+
+	if haDeg, ok := hourAngleDeg(latDeg, declRad, civilTwilightAltitudeDeg); ok {
+		day.CivilDawn = minutesToUTC(date, noonMinutes-4*haDeg)
+		day.CivilDusk = minutesToUTC(date, noonMinutes+4*haDeg)
+	}
+	if haDeg, ok := hourAngleDeg(latDeg, declRad, nauticalTwilightAltitudeDeg); ok {
+		day.NauticalDawn = minutesToUTC(date, noonMinutes-4*haDeg)
+		day.NauticalDusk = minutesToUTC(date, noonMinutes+4*haDeg)
+	}
+	if haDeg, ok := hourAngleDeg(latDeg, declRad, astronomicalTwilightAltitudeDeg); ok {
+		day.AstronomicalDawn = minutesToUTC(date, noonMinutes-4*haDeg)
+		day.AstronomicalDusk = minutesToUTC(date, noonMinutes+4*haDeg)
+	}
+
+	return day
+}
+
+// solarPosition returns the solar declination (radians) and the equation of
+// time (minutes) for date, using the sun's position at UTC noon as a
+// day-level approximation.
+func solarPosition(date time.Time) (declRad, eqTimeMinutes float64) {
+	gamma := 2 * math.Pi / 365 * float64(date.YearDay()-1)
+
+	eqTimeMinutes = 229.18 * (0.000075 +
+		0.001868*math.Cos(gamma) -
+		0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) -
+		0.040849*math.Sin(2*gamma))
+
+	declRad = 0.006918 -
+		0.399912*math.Cos(gamma) +
+		0.070257*math.Sin(gamma) -
+		0.006758*math.Cos(2*gamma) +
+		0.000907*math.Sin(2*gamma) -
+		0.002697*math.Cos(3*gamma) +
+		0.00148*math.Sin(3*gamma)
+
+	return declRad, eqTimeMinutes
+}
+
+// hourAngleDeg solves H = acos((sin(alt) - sin(lat)sin(decl)) / (cos(lat)cos(decl)))
+// for the hour angle, in degrees, at which the sun reaches altitudeDeg. ok is
+// false when the sun never reaches that altitude on this date at this
+// latitude (polar day or polar night), in which case H has no solution.
+func hourAngleDeg(latDeg, declRad, altitudeDeg float64) (haDeg float64, ok bool) {
+	latRad := latDeg * math.Pi / 180
+	altRad := altitudeDeg * math.Pi / 180
+
+	cosH := (math.Sin(altRad) - math.Sin(latRad)*math.Sin(declRad)) / (math.Cos(latRad) * math.Cos(declRad))
+	if cosH < -1 || cosH > 1 {
+		return 0, false
+	}
+	return math.Acos(cosH) * 180 / math.Pi, true
+}
+
+// minutesToUTC returns the UTC instant at the given number of minutes past
+// midnight on date's calendar day, wrapping into the adjacent day if minutes
+// is negative or >= 1440.
+func minutesToUTC(date time.Time, minutes float64) time.Time {
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return midnight.Add(time.Duration(minutes * float64(time.Minute)))
+}
+
+// growingDegreeDays returns the growing-degree-days accumulated on a day
+// with the given max/min temperatures (Celsius), floored at zero since a
+// day colder than baseC contributes no growth.
+func growingDegreeDays(tMaxC, tMinC, baseC float64) float64 {
+	gdd := (tMaxC+tMinC)/2 - baseC
+	if gdd < 0 {
+		return 0
+	}
+	return gdd
+}
+
+// skyConditionFromCloudCover buckets a cloud_cover percentage into the
+// categories NWS forecasts use (clear/few/scattered/broken/overcast),
+// matching the okta-based boundaries cloudCoverPercent (metar_provider.go)
+// maps observed sky_cover codes to.
+func skyConditionFromCloudCover(cloudCoverPercent float64) string {
+	switch {
+	case cloudCoverPercent <= 6.25:
+		return "clear"
+	case cloudCoverPercent <= 31.25:
+		return "few"
+	case cloudCoverPercent <= 50:
+		return "scattered"
+	case cloudCoverPercent <= 87.5:
+		return "broken"
+	default:
+		return "overcast"
+	}
+}
+
+// meanCloudCoverByDate averages hourly cloud_cover readings into one value
+// per calendar date, so insertDailyWeatherData can attach a day-level
+// sky_condition even though the providers only report cloud_cover hourly.
+func meanCloudCoverByDate(hourly []HourlyWeatherRecord) map[string]float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, h := range hourly {
+		date := h.Time.Format("2006-01-02")
+		sums[date] += h.CloudCover
+		counts[date]++
+	}
+
+	means := make(map[string]float64, len(sums))
+	for date, sum := range sums {
+		means[date] = sum / float64(counts[date])
+	}
+	return means
+}
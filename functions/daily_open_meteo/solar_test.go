@@ -0,0 +1,154 @@
+/*
+daily_open_meteo/solar_test.go
+
+Tests for the locally-computed solar position and agronomic helpers.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestComputeSolarDay_AtEquatorNearEoTZeroCrossing checks solar noon at the
+// equator and prime meridian falls within a few minutes of 12:00 UTC, and
+// that each twilight window is symmetric around solar noon. December 25 is
+// used (rather than an equinox) because it's one of the four dates per year
+// the equation of time crosses zero, so solar noon is actually expected to
+// land close to clock noon there; on the equinoxes themselves the equation
+// of time is about 7.7 minutes in magnitude (negative in March, positive in
+// September), which would make a +/-3min assertion wrong.
+func TestComputeSolarDay_AtEquatorNearEoTZeroCrossing(t *testing.T) {
+	date := time.Date(2024, time.December, 25, 0, 0, 0, 0, time.UTC)
+	day := computeSolarDay(date, 0, 0)
+
+	wantNoon := time.Date(2024, time.December, 25, 12, 0, 0, 0, time.UTC)
+	if diff := day.Noon.Sub(wantNoon); diff < -3*time.Minute || diff > 3*time.Minute {
+		t.Errorf("solar noon at the equator on Dec 25 = %s, want within 3min of %s", day.Noon, wantNoon)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		dawn, dusk time.Time
+	}{
+		{"civil", day.CivilDawn, day.CivilDusk},
+		{"nautical", day.NauticalDawn, day.NauticalDusk},
+		{"astronomical", day.AstronomicalDawn, day.AstronomicalDusk},
+	} {
+		if tc.dawn.IsZero() || tc.dusk.IsZero() {
+			t.Errorf("%s twilight missing at the equator on Dec 25", tc.name)
+			continue
+		}
+		beforeNoon := day.Noon.Sub(tc.dawn)
+		afterNoon := tc.dusk.Sub(day.Noon)
+		if diff := beforeNoon - afterNoon; diff < -time.Second || diff > time.Second {
+			t.Errorf("%s twilight not symmetric around solar noon: dawn is %s before noon, dusk is %s after", tc.name, beforeNoon, afterNoon)
+		}
+	}
+}
+
+// TestComputeSolarDay_PolarNight checks that no twilight bound is returned
+// for a location and date where the sun never rises above -18 degrees. On
+// Dec 21 the sun's max elevation only drops below -18 degrees poleward of
+// about 85N (e.g. 78N/Svalbard still gets a max elevation near -11.4
+// degrees, well above the astronomical threshold), so 89N is used here.
+func TestComputeSolarDay_PolarNight(t *testing.T) {
+	midwinter := time.Date(2024, time.December, 21, 0, 0, 0, 0, time.UTC)
+	day := computeSolarDay(midwinter, 89, 15)
+
+	if !day.AstronomicalDawn.IsZero() || !day.AstronomicalDusk.IsZero() {
+		t.Errorf("expected no astronomical twilight at 89N during the Dec 21 polar night, got dawn=%s dusk=%s", day.AstronomicalDawn, day.AstronomicalDusk)
+	}
+}
+
+func TestHourAngleDeg(t *testing.T) {
+	tests := []struct {
+		name        string
+		latDeg      float64
+		declRad     float64
+		altitudeDeg float64
+		wantOk      bool
+		wantDeg     float64
+		tolerance   float64
+	}{
+		{"sunrise/sunset at equator on equinox", 0, 0, sunriseSunsetAltitudeDeg, true, 90.833, 0.01},
+		{"civil twilight at equator on equinox", 0, 0, civilTwilightAltitudeDeg, true, 96, 0.1},
+		{"high latitude on equinox, sun never sinks to -18deg", 85, 0, astronomicalTwilightAltitudeDeg, false, 0, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotDeg, ok := hourAngleDeg(tc.latDeg, tc.declRad, tc.altitudeDeg)
+			if ok != tc.wantOk {
+				t.Fatalf("hourAngleDeg() ok = %v, want %v", ok, tc.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(gotDeg-tc.wantDeg) > tc.tolerance {
+				t.Errorf("hourAngleDeg() = %v, want %v +/- %v", gotDeg, tc.wantDeg, tc.tolerance)
+			}
+		})
+	}
+}
+
+func TestGrowingDegreeDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		tMax, tMin float64
+		want       float64
+	}{
+		{"warm day above base", 25, 15, 10},
+		{"cold day floors at zero", 5, -5, 0},
+		{"exactly at base", 12, 8, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := growingDegreeDays(tc.tMax, tc.tMin, growingDegreeDayBaseC); got != tc.want {
+				t.Errorf("growingDegreeDays(%v, %v, %v) = %v, want %v", tc.tMax, tc.tMin, growingDegreeDayBaseC, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSkyConditionFromCloudCover(t *testing.T) {
+	tests := []struct {
+		cloudCover float64
+		want       string
+	}{
+		{0, "clear"},
+		{20, "few"},
+		{45, "scattered"},
+		{80, "broken"},
+		{100, "overcast"},
+	}
+
+	for _, tc := range tests {
+		if got := skyConditionFromCloudCover(tc.cloudCover); got != tc.want {
+			t.Errorf("skyConditionFromCloudCover(%v) = %q, want %q", tc.cloudCover, got, tc.want)
+		}
+	}
+}
+
+func TestMeanCloudCoverByDate(t *testing.T) {
+	hourly := []HourlyWeatherRecord{
+		{Time: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), CloudCover: 0},
+		{Time: time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC), CloudCover: 100},
+		{Time: time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC), CloudCover: 50},
+	}
+
+	means := meanCloudCoverByDate(hourly)
+	if got := means["2024-03-20"]; got != 50 {
+		t.Errorf("mean cloud cover for 2024-03-20 = %v, want 50", got)
+	}
+	if got := means["2024-03-21"]; got != 50 {
+		t.Errorf("mean cloud cover for 2024-03-21 = %v, want 50", got)
+	}
+}
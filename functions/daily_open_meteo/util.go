@@ -0,0 +1,87 @@
+/*
+daily_open_meteo/util.go
+
+Small helpers shared across the WeatherProvider implementations.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// validFloat64 wraps v as a non-NULL bigquery.NullFloat64, for providers
+// stamping a measurement they actually report.
+func validFloat64(v float64) bigquery.NullFloat64 {
+	return bigquery.NullFloat64{Float64: v, Valid: true}
+}
+
+// validTimestamp wraps t as a non-NULL bigquery.NullTimestamp, for providers
+// stamping a measurement they actually report.
+func validTimestamp(t time.Time) bigquery.NullTimestamp {
+	return bigquery.NullTimestamp{Timestamp: t, Valid: true}
+}
+
+// parseDateRange parses the YYYY-MM-DD start/end query parameters into a
+// half-open [start, end+1day) time.Time range in UTC, so callers can filter
+// timestamped provider responses with a simple Before/After check.
+func parseDateRange(startDate, endDate string) (start, end time.Time, err error) {
+	start, err = time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date %q: %w", startDate, err)
+	}
+	end, err = time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date %q: %w", endDate, err)
+	}
+	end = end.AddDate(0, 0, 1)
+	return start, end, nil
+}
+
+// httpStatusError records the status code of a non-2xx provider response so
+// callers (the BackfillAllSensors retry loop, in particular) can tell a
+// retryable 429/5xx apart from a permanent failure without parsing error
+// strings.
+type httpStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("non-200 status: %d. Body: %s", e.StatusCode, e.Body)
+}
+
+// chunkDateRange splits [startDate, endDate] (inclusive, YYYY-MM-DD) into
+// consecutive windows of at most maxDays days, so a single backfill request
+// never asks a provider for a range larger than it's willing to serve.
+func chunkDateRange(startDate, endDate string, maxDays int) ([][2]string, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date %q: %w", startDate, err)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end_date %q: %w", endDate, err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end_date %q is before start_date %q", endDate, startDate)
+	}
+
+	var windows [][2]string
+	for windowStart := start; !windowStart.After(end); {
+		windowEnd := windowStart.AddDate(0, 0, maxDays-1)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		windows = append(windows, [2]string{windowStart.Format("2006-01-02"), windowEnd.Format("2006-01-02")})
+		windowStart = windowEnd.AddDate(0, 0, 1)
+	}
+	return windows, nil
+}
@@ -0,0 +1,84 @@
+/*
+daily_open_meteo/util_test.go
+
+Tests for the small helpers shared across WeatherProvider implementations.
+
+Copyright (c) 2025 Caden Howell (cadenhowell@gmail.com)
+Developed with assistance from ChatGPT 4o (2025) and Google Gemini 2.5 Pro (2025).
+Apache 2.0 Licensed as described in the file LICENSE
+*/
+
+package weather_function
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkDateRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		start, end       string
+		maxDays          int
+		wantWindows      [][2]string
+		wantErrSubstring string
+	}{
+		{
+			name:  "range shorter than maxDays is a single window",
+			start: "2024-01-01", end: "2024-01-05", maxDays: 31,
+			wantWindows: [][2]string{{"2024-01-01", "2024-01-05"}},
+		},
+		{
+			name:  "range exactly maxDays is a single window",
+			start: "2024-01-01", end: "2024-01-31", maxDays: 31,
+			wantWindows: [][2]string{{"2024-01-01", "2024-01-31"}},
+		},
+		{
+			name:  "range longer than maxDays splits, last window shrinks to fit end",
+			start: "2024-01-01", end: "2024-02-10", maxDays: 31,
+			wantWindows: [][2]string{{"2024-01-01", "2024-01-31"}, {"2024-02-01", "2024-02-10"}},
+		},
+		{
+			name:  "single day range",
+			start: "2024-03-01", end: "2024-03-01", maxDays: 31,
+			wantWindows: [][2]string{{"2024-03-01", "2024-03-01"}},
+		},
+		{
+			name:             "end before start is an error",
+			start:            "2024-03-10",
+			end:              "2024-03-01",
+			maxDays:          31,
+			wantErrSubstring: "is before",
+		},
+		{
+			name:             "invalid start_date is an error",
+			start:            "not-a-date",
+			end:              "2024-03-01",
+			maxDays:          31,
+			wantErrSubstring: "invalid start_date",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := chunkDateRange(tc.start, tc.end, tc.maxDays)
+			if tc.wantErrSubstring != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstring) {
+					t.Fatalf("chunkDateRange() error = %v, want substring %q", err, tc.wantErrSubstring)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("chunkDateRange() unexpected error: %v", err)
+			}
+			if len(got) != len(tc.wantWindows) {
+				t.Fatalf("chunkDateRange() = %v, want %v", got, tc.wantWindows)
+			}
+			for i, w := range got {
+				if w != tc.wantWindows[i] {
+					t.Errorf("window %d = %v, want %v", i, w, tc.wantWindows[i])
+				}
+			}
+		})
+	}
+}